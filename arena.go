@@ -0,0 +1,137 @@
+package jsonpart
+
+import (
+	"strconv"
+)
+
+// Arena may be used for fast creation and re-use of Values.
+//
+// Typical Arena lifecycle:
+//
+//  1. Construct Values via the Arena and Value.Set* calls.
+//  2. Marshal the constructed Values with MarshalBytes or MarshalString call.
+//  3. Reset all the constructed Values at once by Arena.Reset call.
+//  4. Go to 1 and re-use the Arena.
+//
+// It is unsafe calling Arena methods from concurrent goroutines.
+// Use per-goroutine Arenas.
+type Arena struct {
+	b []byte
+	c cache
+}
+
+// Reset resets all the Values allocated by a.
+//
+// Values previously allocated by a cannot be used after the Reset call.
+func (a *Arena) Reset() {
+	a.b = a.b[:0]
+	a.c.reset()
+}
+
+// NewObject returns new empty object value.
+//
+// New entries may be added to the returned object via Set call.
+//
+// The returned object is valid until Reset is called on a.
+func (a *Arena) NewObject() *Value {
+	v := a.c.getValue()
+	v.t = TypeObject
+	v.o.reset()
+	// Keys added via Set are stored unescaped.
+	v.o.keysUnescaped = true
+	return v
+}
+
+// NewArray returns new empty array value.
+//
+// New entries may be added to the returned array via Set* calls.
+//
+// The returned array is valid until Reset is called on a.
+func (a *Arena) NewArray() *Value {
+	v := a.c.getValue()
+	v.t = TypeArray
+	v.a = v.a[:0]
+	v.frozen = false
+	return v
+}
+
+// NewString returns new string value containing s.
+//
+// The returned string is valid until Reset is called on a.
+func (a *Arena) NewString(s string) *Value {
+	v := a.c.getValue()
+	v.t = TypeString
+	bLen := len(a.b)
+	a.b = append(a.b, s...)
+	v.s = b2s(a.b[bLen:])
+	return v
+}
+
+// NewStringBytes returns new string value containing b.
+//
+// The returned string is valid until Reset is called on a.
+func (a *Arena) NewStringBytes(b []byte) *Value {
+	return a.NewString(b2s(b))
+}
+
+// NewNumberFloat64 returns new number value containing f.
+//
+// The returned number is valid until Reset is called on a.
+func (a *Arena) NewNumberFloat64(f float64) *Value {
+	v := a.c.getValue()
+	v.t = TypeNumber
+	bLen := len(a.b)
+	a.b = strconv.AppendFloat(a.b, f, 'g', -1, 64)
+	v.s = b2s(a.b[bLen:])
+	return v
+}
+
+// NewNumberInt returns new number value containing n.
+//
+// The returned number is valid until Reset is called on a.
+func (a *Arena) NewNumberInt(n int) *Value {
+	v := a.c.getValue()
+	v.t = TypeNumber
+	bLen := len(a.b)
+	a.b = strconv.AppendInt(a.b, int64(n), 10)
+	v.s = b2s(a.b[bLen:])
+	return v
+}
+
+// NewNumberString returns new number value containing s.
+//
+// s must contain valid JSON number.
+//
+// The returned number is valid until Reset is called on a.
+func (a *Arena) NewNumberString(s string) *Value {
+	v := a.NewString(s)
+	v.t = TypeNumber
+	return v
+}
+
+// NewNull returns new null value.
+//
+// The returned value is valid until Reset is called on a.
+func (a *Arena) NewNull() *Value {
+	v := a.c.getValue()
+	v.t = TypeNull
+	return v
+}
+
+// NewTrue returns new true value.
+//
+// The returned value is valid until Reset is called on a.
+func (a *Arena) NewTrue() *Value {
+	v := a.c.getValue()
+	v.t = TypeTrue
+	return v
+}
+
+// NewFalse returns new false value.
+//
+// The returned value is valid until Reset is called on a.
+func (a *Arena) NewFalse() *Value {
+	v := a.c.getValue()
+	v.t = TypeFalse
+	return v
+}
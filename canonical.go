@@ -0,0 +1,213 @@
+package jsonpart
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MarshalCanonical returns v marshaled according to JSON Canonicalization Scheme
+// (RFC 8785).
+//
+// Object keys are sorted by their UTF-16 code units, numbers are formatted
+// as ECMAScript does and strings contain only the mandatory escape sequences,
+// so equal data always results in the same output. The output is suitable
+// for hashing.
+//
+// An error is returned if v contains numbers, which cannot be represented
+// by IEEE 754 double, or strings with invalid UTF-8.
+func (v *Value) MarshalCanonical() ([]byte, error) {
+	return appendCanonical(nil, v)
+}
+
+// MarshalCanonical returns o marshaled according to JSON Canonicalization Scheme
+// (RFC 8785).
+//
+// See Value.MarshalCanonical for details.
+func (o *Object) MarshalCanonical() ([]byte, error) {
+	return appendCanonicalObject(nil, o)
+}
+
+func appendCanonical(dst []byte, v *Value) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	switch v.vType() {
+	case TypeObject:
+		return appendCanonicalObject(dst, &v.o)
+	case TypeArray:
+		dst = append(dst, '[')
+		for i, vv := range v.a {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			dst, err = appendCanonical(dst, vv)
+			if err != nil {
+				return nil, fmt.Errorf("cannot marshal array item #%d: %s", i, err)
+			}
+		}
+		return append(dst, ']'), nil
+	case TypeString:
+		if !utf8.ValidString(v.s) {
+			return nil, fmt.Errorf("string contains invalid UTF-8: %q", startEndString(v.s))
+		}
+		return appendJSONString(dst, v.s, 0), nil
+	case TypeNumber:
+		f, err := parse(v.s)
+		if err != nil {
+			return nil, err
+		}
+		// The fast path in parse may be off by one ulp for numbers with exponent,
+		// while canonical output requires correctly rounded value.
+		if ff, err := strconv.ParseFloat(v.s, 64); err == nil {
+			f = ff
+		}
+		return appendESNumber(dst, f)
+	case TypeTrue:
+		return append(dst, "true"...), nil
+	case TypeFalse:
+		return append(dst, "false"...), nil
+	case TypeNull:
+		return append(dst, "null"...), nil
+	default:
+		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
+	}
+}
+
+func appendCanonicalObject(dst []byte, o *Object) ([]byte, error) {
+	if o == nil {
+		return append(dst, "null"...), nil
+	}
+	o.unescapeKeys()
+	kvs := append([]kv(nil), o.kvs...)
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return lessUTF16(kvs[i].k, kvs[j].k)
+	})
+	dst = append(dst, '{')
+	for i, kv := range kvs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if !utf8.ValidString(kv.k) {
+			return nil, fmt.Errorf("object key contains invalid UTF-8: %q", startEndString(kv.k))
+		}
+		dst = appendJSONString(dst, kv.k, 0)
+		dst = append(dst, ':')
+		var err error
+		dst, err = appendCanonical(dst, kv.v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal value for key %q: %s", kv.k, err)
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// lessUTF16 returns true if a is less than b when both are compared
+// as sequences of UTF-16 code units.
+func lessUTF16(a, b string) bool {
+	var abuf, bbuf [2]rune
+	var an, bn int
+	for {
+		if an == 0 {
+			if len(a) == 0 {
+				return len(b) > 0 || bn > 0
+			}
+			r, size := utf8.DecodeRuneInString(a)
+			a = a[size:]
+			an = putUTF16(&abuf, r)
+		}
+		if bn == 0 {
+			if len(b) == 0 {
+				return false
+			}
+			r, size := utf8.DecodeRuneInString(b)
+			b = b[size:]
+			bn = putUTF16(&bbuf, r)
+		}
+		// Compare the next code units.
+		ca := abuf[len(abuf)-an]
+		cb := bbuf[len(bbuf)-bn]
+		if ca != cb {
+			return ca < cb
+		}
+		an--
+		bn--
+	}
+}
+
+// putUTF16 puts UTF-16 code units for r at the end of buf and returns their number.
+func putUTF16(buf *[2]rune, r rune) int {
+	if r >= 0x10000 {
+		buf[0], buf[1] = utf16.EncodeRune(r)
+		return 2
+	}
+	buf[1] = r
+	return 1
+}
+
+// appendESNumber appends f formatted as ECMAScript Number.prototype.toString does.
+func appendESNumber(dst []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("cannot marshal %v as JSON number", f)
+	}
+	if f == 0 {
+		// This also covers -0.
+		return append(dst, '0'), nil
+	}
+	if f < 0 {
+		dst = append(dst, '-')
+		f = -f
+	}
+
+	// Obtain the shortest decimal digits, which round-trip to f,
+	// and the decimal exponent n such as f = 0.digits * 10^n.
+	var buf [32]byte
+	b := strconv.AppendFloat(buf[:0], f, 'e', -1, 64)
+	ePos := 0
+	for b[ePos] != 'e' {
+		ePos++
+	}
+	exp, _ := strconv.Atoi(string(b[ePos+1:]))
+	digits := make([]byte, 0, ePos)
+	for _, c := range b[:ePos] {
+		if c != '.' {
+			digits = append(digits, c)
+		}
+	}
+	k := len(digits)
+	n := exp + 1
+
+	switch {
+	case k <= n && n <= 21:
+		dst = append(dst, digits...)
+		for i := 0; i < n-k; i++ {
+			dst = append(dst, '0')
+		}
+	case 0 < n && n <= 21:
+		dst = append(dst, digits[:n]...)
+		dst = append(dst, '.')
+		dst = append(dst, digits[n:]...)
+	case -6 < n && n <= 0:
+		dst = append(dst, '0', '.')
+		for i := 0; i < -n; i++ {
+			dst = append(dst, '0')
+		}
+		dst = append(dst, digits...)
+	default:
+		dst = append(dst, digits[0])
+		if k > 1 {
+			dst = append(dst, '.')
+			dst = append(dst, digits[1:]...)
+		}
+		dst = append(dst, 'e')
+		if n-1 >= 0 {
+			dst = append(dst, '+')
+		}
+		dst = strconv.AppendInt(dst, int64(n-1), 10)
+	}
+	return dst, nil
+}
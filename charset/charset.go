@@ -0,0 +1,116 @@
+// Package charset parses JSON embedded in pages encoded in non-UTF-8 charsets
+// such as GBK, GB18030, Big5, Shift_JIS and Latin-1.
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffreyzj/jsonpart"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// ParseBytes parses b encoded in the given charset,
+// such as "gbk", "gb18030", "big5", "shift_jis" or "iso-8859-1".
+//
+// b is converted to UTF-8 before parsing, so string values are valid UTF-8
+// and \u escapes are mixed with properly decoded text. Bytes, which are
+// invalid in the charset, are replaced with U+FFFD.
+// The charset is detected with Detect if it is empty.
+// Byte order mark at the start of b takes precedence over the charset.
+//
+// Charset names are resolved according to the WHATWG Encoding Standard,
+// e.g. "iso-8859-1" means windows-1252 like in browsers.
+//
+// See jsonpart.Parse for partialKey. Offsets returned by Value.Offset refer to the UTF-8 text,
+// so they differ from offsets in b for non-ASCII multibyte charsets.
+func ParseBytes(b []byte, charset string, partialKey ...string) (*jsonpart.Value, error) {
+	if charset == "" {
+		charset = Detect(b)
+	}
+	data, err := decode(b, charset)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpart.ParseBytes(data, partialKey...)
+}
+
+func decode(b []byte, charset string) ([]byte, error) {
+	if name, n := detectBOM(b); n > 0 {
+		// Byte order mark takes precedence over the charset like in browsers.
+		charset = name
+		b = b[n:]
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q: %w", charset, err)
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" && utf8.Valid(b) {
+		// Fast path - nothing to decode.
+		return b, nil
+	}
+	data, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode %s text: %w", charset, err)
+	}
+	return data, nil
+}
+
+// prescanLen is the number of bytes inspected by Detect
+// for charset declarations, like browsers do.
+const prescanLen = 1024
+
+var (
+	metaCharsetRe = regexp.MustCompile(`(?i)<meta\s[^>]*?charset\s*=\s*["']?\s*([a-z0-9_:.+-]+)`)
+	xmlEncodingRe = regexp.MustCompile(`(?i)<\?xml\s[^>]*?encoding\s*=\s*["']([a-z0-9_:.+-]+)`)
+)
+
+// Detect returns the charset of b.
+//
+// The charset is detected from byte order mark, <meta charset> tag,
+// <meta http-equiv="Content-Type"> tag or XML declaration
+// in the first 1024 bytes of b. "utf-8" is returned if no supported
+// charset is declared.
+//
+// The returned name is canonical according to the WHATWG Encoding Standard,
+// e.g. "gbk" for "GB2312".
+func Detect(b []byte) string {
+	if name, n := detectBOM(b); n > 0 {
+		return name
+	}
+	head := b
+	if len(head) > prescanLen {
+		head = head[:prescanLen]
+	}
+	for _, re := range []*regexp.Regexp{metaCharsetRe, xmlEncodingRe} {
+		m := re.FindSubmatch(head)
+		if m == nil {
+			continue
+		}
+		enc, err := htmlindex.Get(strings.TrimSpace(string(m[1])))
+		if err != nil {
+			continue
+		}
+		if name, err := htmlindex.Name(enc); err == nil {
+			return name
+		}
+	}
+	return "utf-8"
+}
+
+// detectBOM returns the charset and the length of byte order mark at the start of b.
+func detectBOM(b []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(b, []byte("\xef\xbb\xbf")):
+		return "utf-8", 3
+	case bytes.HasPrefix(b, []byte("\xff\xfe")):
+		return "utf-16le", 2
+	case bytes.HasPrefix(b, []byte("\xfe\xff")):
+		return "utf-16be", 2
+	default:
+		return "", 0
+	}
+}
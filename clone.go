@@ -0,0 +1,91 @@
+package jsonpart
+
+import (
+	"fmt"
+)
+
+// Clone returns a deep copy of v.
+//
+// The returned value is allocated in independent memory, so it doesn't
+// reference the parser buffer or other values. It remains valid after v
+// is modified or released, and it may be safely cached for a long time.
+func (v *Value) Clone() *Value {
+	if v == nil {
+		return nil
+	}
+	var c cloner
+	n, size := cloneSize(v)
+	c.c.vs = make([]Value, 0, n)
+	c.b = make([]byte, 0, size)
+	return c.clone(v)
+}
+
+// cloner copies values into c and strings into b.
+//
+// b is pre-allocated with the exact capacity, so strings referencing b
+// stay valid while it grows.
+type cloner struct {
+	b []byte
+	c cache
+}
+
+func (c *cloner) clone(v *Value) *Value {
+	vv := c.c.getValue()
+	*vv = Value{t: v.t}
+	switch v.t {
+	case TypeTrue, TypeFalse, TypeNull:
+		// Scalars are copied as well, so the clone shares nothing with v.
+	case TypeObject:
+		vv.o.keysUnescaped = v.o.keysUnescaped
+		vv.o.loneSurrogateKeys = v.o.loneSurrogateKeys
+		vv.o.kvs = make([]kv, len(v.o.kvs))
+		for i, kv := range v.o.kvs {
+			vv.o.kvs[i].k = c.copyString(kv.k)
+			vv.o.kvs[i].v = c.clone(kv.v)
+		}
+	case TypeArray:
+		vv.a = make([]*Value, len(v.a))
+		for i, item := range v.a {
+			vv.a[i] = c.clone(item)
+		}
+	case TypeString, typeRawString, TypeNumber:
+		vv.s = c.copyString(v.s)
+		vv.loneSurrogates = v.loneSurrogates
+	default:
+		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
+	}
+	return vv
+}
+
+func (c *cloner) copyString(s string) string {
+	bLen := len(c.b)
+	c.b = append(c.b, s...)
+	return b2s(c.b[bLen:])
+}
+
+// cloneSize returns the number of values to allocate and the total
+// length of strings to copy for cloning v.
+func cloneSize(v *Value) (int, int) {
+	switch v.t {
+	case TypeObject:
+		n, size := 1, 0
+		for _, kv := range v.o.kvs {
+			nn, ss := cloneSize(kv.v)
+			n += nn
+			size += ss + len(kv.k)
+		}
+		return n, size
+	case TypeArray:
+		n, size := 1, 0
+		for _, item := range v.a {
+			nn, ss := cloneSize(item)
+			n += nn
+			size += ss
+		}
+		return n, size
+	case TypeString, typeRawString, TypeNumber:
+		return 1, len(v.s)
+	default:
+		return 1, 0
+	}
+}
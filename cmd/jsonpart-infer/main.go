@@ -0,0 +1,78 @@
+// Command jsonpart-infer infers JSON Schema or Go types from sample JSON files.
+//
+// Usage:
+//
+//	jsonpart-infer [-key partialKey] [-go TypeName] [file ...]
+//
+// Every file is parsed as a separate sample. The standard input is read
+// if no files are given. Shapes of all the samples are merged.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jeffreyzj/jsonpart"
+	"github.com/jeffreyzj/jsonpart/infer"
+)
+
+var (
+	partialKey = flag.String("key", "", "Optional partial key to locate the JSON value in each sample, e.g. in HTML pages")
+	goType     = flag.String("go", "", "Emit Go type declarations with the given root type name instead of JSON Schema")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var s infer.Shape
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, path := range files {
+		v, err := parseFile(path)
+		if err != nil {
+			fatalf("cannot parse %q: %s", path, err)
+		}
+		s.Add(v)
+	}
+
+	if *goType == "" {
+		fmt.Println(s.String())
+		return
+	}
+	src, err := s.GoStruct(*goType)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	os.Stdout.Write(src)
+}
+
+func parseFile(path string) (*jsonpart.Value, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if *partialKey != "" {
+		keys = append(keys, *partialKey)
+	}
+	return jsonpart.ParseBytes(data, keys...)
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,186 @@
+// Command jsonpart extracts JSON values embedded in HTML pages and other text.
+//
+// Usage:
+//
+//	jsonpart [-key partialKey] [-path a.b.0] [-filter expr] [-pretty] [-r] [file ...]
+//
+// The -filter expression is applied to the value located by -key and -path.
+// See github.com/jeffreyzj/jsonpart/filter for the filter syntax.
+// Every filter output is printed on a separate line.
+//
+// The standard input is read if no files are given. Every file is processed
+// independently. Results for multiple files are preceded by "==> file <==" headers.
+//
+// Exit codes:
+//
+//	0 - success
+//	1 - parse error
+//	2 - usage error
+//	3 - partialKey or path not found
+//	4 - I/O error
+//	5 - filter error
+//
+// The highest exit code is returned if processing of multiple files fails.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jeffreyzj/jsonpart"
+	"github.com/jeffreyzj/jsonpart/charset"
+	"github.com/jeffreyzj/jsonpart/filter"
+)
+
+const (
+	exitOK       = 0
+	exitParse    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitIO       = 4
+	exitFilter   = 5
+)
+
+var (
+	partialKey = flag.String("key", "", "Partial key to locate the embedded JSON value. The whole input is parsed as JSON if empty")
+	path       = flag.String("path", "", "Dot-separated keys path inside the located value such as params.1, or JSON pointer such as /params/1")
+	filterExpr = flag.String("filter", "", "jq-like filter to apply to the located value, such as '.items[] | select(.price > 10) | .name'")
+	pretty     = flag.Bool("pretty", false, "Pretty-print the output")
+	rawOutput  = flag.Bool("r", false, "Output strings without quotes and escaping")
+	inCharset  = flag.String("charset", "", "Input charset such as gbk or big5; use auto for detecting it from BOM and <meta> tags. Input is treated as UTF-8 if empty")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	keys, err := parsePath(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "jsonpart: %s\n", err)
+		os.Exit(exitUsage)
+	}
+	var f *filter.Filter
+	if *filterExpr != "" {
+		f, err = filter.Compile(*filterExpr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "jsonpart: %s\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	exitCode := exitOK
+	for i, file := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", file)
+		}
+		code, err := processFile(file, keys, f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "jsonpart: %s: %s\n", file, err)
+		}
+		if code > exitCode {
+			exitCode = code
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// parsePath parses -path flag value into keys.
+func parsePath(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(p, "/") {
+		keys, err := jsonpart.ParsePointer(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -path: %w", err)
+		}
+		return keys, nil
+	}
+	return strings.Split(p, "."), nil
+}
+
+func processFile(file string, keys []string, f *filter.Filter) (int, error) {
+	data, err := readFile(file)
+	if err != nil {
+		return exitIO, err
+	}
+	var v *jsonpart.Value
+	switch *inCharset {
+	case "":
+		v, err = jsonpart.ParseBytes(data, *partialKey)
+	case "auto":
+		v, err = charset.ParseBytes(data, "", *partialKey)
+	default:
+		v, err = charset.ParseBytes(data, *inCharset, *partialKey)
+	}
+	if err != nil {
+		if errors.Is(err, jsonpart.ErrPartialKeyNotFound) {
+			return exitNotFound, err
+		}
+		return exitParse, err
+	}
+	r := v.Get(keys...)
+	if r == nil {
+		return exitNotFound, fmt.Errorf("cannot find path %q", *path)
+	}
+	if f == nil {
+		if err := writeValue(os.Stdout, r); err != nil {
+			return exitIO, err
+		}
+		return exitOK, nil
+	}
+	// Write the outputs emitted before the filter error like jq does.
+	outs, err := f.Run(r)
+	for _, out := range outs {
+		if err := writeValue(os.Stdout, out); err != nil {
+			return exitIO, err
+		}
+	}
+	if err != nil {
+		return exitFilter, err
+	}
+	return exitOK, nil
+}
+
+func readFile(file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
+
+func writeValue(w io.Writer, v *jsonpart.Value) error {
+	var data []byte
+	if *rawOutput && v.Type() == jsonpart.TypeString {
+		s, _ := v.String()
+		data = append([]byte(s), '\n')
+	} else {
+		data = v.MarshalWith(jsonpart.MarshalOptions{
+			Indent:          indent(),
+			TrailingNewline: true,
+		})
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func indent() string {
+	if *pretty {
+		return "  "
+	}
+	return ""
+}
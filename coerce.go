@@ -0,0 +1,247 @@
+package jsonpart
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Coercer obtains values by keys path with loose type conversion.
+//
+// It is intended for messy data where the same field may contain
+// "12.50", 12.5 or "yes" depending on the source. Conversion rules:
+//
+//   - Numbers are accepted as JSON numbers or as strings containing a number
+//     in JSON syntax, optionally surrounded by whitespace and prefixed by '+'.
+//   - Integer accessors accept numbers with zero fractional part such as 3.0 or 1e3.
+//     Other fractional numbers are rejected instead of being truncated.
+//   - true and false are converted to 1 and 0 by numeric accessors.
+//   - Bool accepts true, false, numbers 1 and 0 and case-insensitive strings
+//     "true", "yes", "y", "on", "1", "false", "no", "n", "off" and "0".
+//   - String accepts strings, numbers (the original text) and true/false.
+//   - null, objects and arrays are never coerced.
+//
+// Every accessor reports whether a conversion between JSON types happened.
+type Coercer struct {
+	v *Value
+}
+
+// Coerce returns Coercer for v.
+func (v *Value) Coerce() Coercer {
+	return Coercer{v: v}
+}
+
+// Float64 returns float64 by the given keys path.
+//
+// coerced is set to true if the value isn't a JSON number.
+func (c Coercer) Float64(keys ...string) (f float64, coerced bool, err error) {
+	s, coerced, err := c.numberText(keys)
+	if err != nil {
+		return 0, coerced, err
+	}
+	f, err = parse(s)
+	if err != nil {
+		return 0, coerced, err
+	}
+	return f, coerced, nil
+}
+
+// Int64 returns int64 by the given keys path.
+//
+// coerced is set to true if the value isn't a JSON integer number.
+func (c Coercer) Int64(keys ...string) (n int64, coerced bool, err error) {
+	s, coerced, err := c.numberText(keys)
+	if err != nil {
+		return 0, coerced, err
+	}
+	if n, err := parseInt64(s); err == nil {
+		return n, coerced, nil
+	}
+	// Parse the number exactly, since float64 cannot hold all the int64 values.
+	r, err := parseBigRat(s)
+	if err != nil {
+		return 0, coerced, err
+	}
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, coerced, fmt.Errorf("number %q cannot be converted to int64 without loss", s)
+	}
+	return r.Num().Int64(), true, nil
+}
+
+// Int returns int by the given keys path.
+//
+// coerced is set to true if the value isn't a JSON integer number.
+func (c Coercer) Int(keys ...string) (n int, coerced bool, err error) {
+	n64, coerced, err := c.Int64(keys...)
+	if err != nil {
+		return 0, coerced, err
+	}
+	n = int(n64)
+	if int64(n) != n64 {
+		return 0, coerced, fmt.Errorf("number %d doesn't fit int", n64)
+	}
+	return n, coerced, nil
+}
+
+// Uint64 returns uint64 by the given keys path.
+//
+// coerced is set to true if the value isn't a JSON integer number.
+func (c Coercer) Uint64(keys ...string) (n uint64, coerced bool, err error) {
+	s, coerced, err := c.numberText(keys)
+	if err != nil {
+		return 0, coerced, err
+	}
+	if n, err := parseUint64(s); err == nil {
+		return n, coerced, nil
+	}
+	// Parse the number exactly, since float64 cannot hold all the uint64 values.
+	r, err := parseBigRat(s)
+	if err != nil {
+		return 0, coerced, err
+	}
+	if !r.IsInt() || !r.Num().IsUint64() {
+		return 0, coerced, fmt.Errorf("number %q cannot be converted to uint64 without loss", s)
+	}
+	return r.Num().Uint64(), true, nil
+}
+
+// Bool returns bool by the given keys path.
+//
+// coerced is set to true if the value isn't JSON true or false.
+func (c Coercer) Bool(keys ...string) (b bool, coerced bool, err error) {
+	v, err := c.get(keys)
+	if err != nil {
+		return false, false, err
+	}
+	switch v.vType() {
+	case TypeTrue:
+		return true, false, nil
+	case TypeFalse:
+		return false, false, nil
+	case TypeNumber:
+		switch f, err := parse(v.s); {
+		case err == nil && f == 1:
+			return true, true, nil
+		case err == nil && f == 0:
+			return false, true, nil
+		}
+		return false, true, fmt.Errorf("number %q cannot be converted to bool; only 0 and 1 are allowed", v.s)
+	case TypeString:
+		switch strings.ToLower(strings.TrimSpace(v.s)) {
+		case "true", "yes", "y", "on", "1":
+			return true, true, nil
+		case "false", "no", "n", "off", "0":
+			return false, true, nil
+		}
+		return false, true, fmt.Errorf("string %q cannot be converted to bool", startEndString(v.s))
+	default:
+		return false, false, fmt.Errorf("value doesn't contain bool; it contains %s", v.vType())
+	}
+}
+
+// String returns string by the given keys path.
+//
+// coerced is set to true if the value isn't a JSON string.
+func (c Coercer) String(keys ...string) (s string, coerced bool, err error) {
+	v, err := c.get(keys)
+	if err != nil {
+		return "", false, err
+	}
+	switch v.vType() {
+	case TypeString, TypeNumber:
+		return v.s, v.t == TypeNumber, nil
+	case TypeTrue:
+		return "true", true, nil
+	case TypeFalse:
+		return "false", true, nil
+	default:
+		return "", false, fmt.Errorf("value doesn't contain string; it contains %s", v.vType())
+	}
+}
+
+func (c Coercer) get(keys []string) (*Value, error) {
+	v := c.v.Get(keys...)
+	if v == nil {
+		return nil, fmt.Errorf("cannot find value at %q", keys)
+	}
+	return v, nil
+}
+
+// numberText returns JSON number text for the value at the given keys path.
+func (c Coercer) numberText(keys []string) (string, bool, error) {
+	v, err := c.get(keys)
+	if err != nil {
+		return "", false, err
+	}
+	switch v.vType() {
+	case TypeNumber:
+		return v.s, false, nil
+	case TypeTrue:
+		return "1", true, nil
+	case TypeFalse:
+		return "0", true, nil
+	case TypeString:
+		s := strings.TrimSpace(v.s)
+		s = strings.TrimPrefix(s, "+")
+		if !isJSONNumber(s) {
+			return "", true, fmt.Errorf("string %q doesn't contain number", startEndString(v.s))
+		}
+		return s, true, nil
+	default:
+		return "", false, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
+	}
+}
+
+// GetFloat64Loose returns float64 value by the given keys path
+// with loose type conversion. See Coercer for conversion rules.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// 0 is returned for non-existing keys path or for value, which cannot be converted.
+func (v *Value) GetFloat64Loose(keys ...string) float64 {
+	f, _, _ := v.Coerce().Float64(keys...)
+	return f
+}
+
+// GetIntLoose returns int value by the given keys path
+// with loose type conversion. See Coercer for conversion rules.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// 0 is returned for non-existing keys path or for value, which cannot be converted.
+func (v *Value) GetIntLoose(keys ...string) int {
+	n, _, _ := v.Coerce().Int(keys...)
+	return n
+}
+
+// GetInt64Loose returns int64 value by the given keys path
+// with loose type conversion. See Coercer for conversion rules.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// 0 is returned for non-existing keys path or for value, which cannot be converted.
+func (v *Value) GetInt64Loose(keys ...string) int64 {
+	n, _, _ := v.Coerce().Int64(keys...)
+	return n
+}
+
+// GetBoolLoose returns bool value by the given keys path
+// with loose type conversion. See Coercer for conversion rules.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// false is returned for non-existing keys path or for value, which cannot be converted.
+func (v *Value) GetBoolLoose(keys ...string) bool {
+	b, _, _ := v.Coerce().Bool(keys...)
+	return b
+}
+
+// GetStringLoose returns string value by the given keys path
+// with loose type conversion. See Coercer for conversion rules.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// Empty string is returned for non-existing keys path or for value, which cannot be converted.
+func (v *Value) GetStringLoose(keys ...string) string {
+	s, _, _ := v.Coerce().String(keys...)
+	return s
+}
@@ -0,0 +1,275 @@
+package jsonpart
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// EqualOptions contains options for EqualWith.
+type EqualOptions struct {
+	// IgnoreKeyOrder treats objects with the same entries in distinct order as equal.
+	IgnoreKeyOrder bool
+
+	// NumericNumbers compares numbers by their values instead of their text,
+	// so 1, 1.0 and 1e0 are equal.
+	NumericNumbers bool
+}
+
+// Equal returns true if a and b are structurally equal.
+//
+// Objects must have the same entries in the same order and numbers
+// must have the same text. Use EqualWith for relaxing these rules.
+func Equal(a, b *Value) bool {
+	return EqualWith(a, b, EqualOptions{})
+}
+
+// EqualWith returns true if a and b are structurally equal according to opts.
+func EqualWith(a, b *Value, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a == b {
+		return true
+	}
+	ta := a.vType()
+	if ta != b.vType() {
+		return false
+	}
+	switch ta {
+	case TypeObject:
+		return objectsEqual(&a.o, &b.o, opts)
+	case TypeArray:
+		if len(a.a) != len(b.a) {
+			return false
+		}
+		for i := range a.a {
+			if !EqualWith(a.a[i], b.a[i], opts) {
+				return false
+			}
+		}
+		return true
+	case TypeString:
+		return a.s == b.s
+	case TypeNumber:
+		if opts.NumericNumbers {
+			return numbersEqual(a.s, b.s)
+		}
+		return a.s == b.s
+	default:
+		// true, false and null
+		return true
+	}
+}
+
+func objectsEqual(a, b *Object, opts EqualOptions) bool {
+	if len(a.kvs) != len(b.kvs) {
+		return false
+	}
+	a.unescapeKeys()
+	b.unescapeKeys()
+	if opts.IgnoreKeyOrder {
+		// Match entries as a multiset, so duplicate keys are compared
+		// symmetrically.
+		idxs := make(map[string][]int, len(b.kvs))
+		for i, kv := range b.kvs {
+			idxs[kv.k] = append(idxs[kv.k], i)
+		}
+		for _, kv := range a.kvs {
+			candidates := idxs[kv.k]
+			j := -1
+			for n, i := range candidates {
+				if EqualWith(kv.v, b.kvs[i].v, opts) {
+					j = n
+					break
+				}
+			}
+			if j < 0 {
+				return false
+			}
+			idxs[kv.k] = append(candidates[:j], candidates[j+1:]...)
+		}
+		return true
+	}
+	for i, kv := range a.kvs {
+		if kv.k != b.kvs[i].k || !EqualWith(kv.v, b.kvs[i].v, opts) {
+			return false
+		}
+	}
+	return true
+}
+
+// numbersEqual returns true if numbers a and b have equal values.
+func numbersEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	fa, err := parse(a)
+	if err != nil {
+		return false
+	}
+	fb, err := parse(b)
+	if err != nil {
+		return false
+	}
+	if fa != fb {
+		return false
+	}
+	// Distinct numbers may be rounded to the same float64,
+	// so compare them with higher precision.
+	na, err := parseInt64(a)
+	if err == nil {
+		if nb, err := parseInt64(b); err == nil {
+			return na == nb
+		}
+	}
+	ba, _, err := big.ParseFloat(a, 10, 256, big.ToNearestEven)
+	if err != nil {
+		// Inf can be compared as float64.
+		return true
+	}
+	bb, _, err := big.ParseFloat(b, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return true
+	}
+	return ba.Cmp(bb) == 0
+}
+
+// ChangeKind is the kind of Change.
+type ChangeKind int
+
+const (
+	// ChangeAdded means the value is missing in the old value.
+	ChangeAdded ChangeKind = 0
+
+	// ChangeRemoved means the value is missing in the new value.
+	ChangeRemoved ChangeKind = 1
+
+	// ChangeModified means the value differs between the old and the new value.
+	ChangeModified ChangeKind = 2
+)
+
+// String returns string representation of k.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Change is a single difference found by Diff.
+type Change struct {
+	// Kind is the kind of the change.
+	Kind ChangeKind
+
+	// Path is the keys path to the changed value.
+	//
+	// Array indexes are represented as decimal numbers.
+	Path []string
+
+	// Old is the old value. It is nil for ChangeAdded.
+	Old *Value
+
+	// New is the new value. It is nil for ChangeRemoved.
+	New *Value
+}
+
+// String returns human-readable representation of c.
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("added %q: %s", c.Path, c.New.MarshalString())
+	case ChangeRemoved:
+		return fmt.Sprintf("removed %q: %s", c.Path, c.Old.MarshalString())
+	default:
+		return fmt.Sprintf("modified %q: %s -> %s", c.Path, c.Old.MarshalString(), c.New.MarshalString())
+	}
+}
+
+// Diff returns the list of changes required for turning a into b.
+//
+// Objects are compared by keys regardless of their order, arrays
+// are compared item by item, and numbers are compared by their values.
+// Items removed from the end of arrays are reported in descending index order,
+// so the changes may be applied one by one.
+//
+// Old and New values in the returned changes reference a and b.
+func Diff(a, b *Value) []Change {
+	var d differ
+	d.diff(nil, a, b)
+	return d.changes
+}
+
+var diffOptions = EqualOptions{
+	IgnoreKeyOrder: true,
+	NumericNumbers: true,
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, path []string, oldV, newV *Value) {
+	d.changes = append(d.changes, Change{
+		Kind: kind,
+		Path: append([]string(nil), path...),
+		Old:  oldV,
+		New:  newV,
+	})
+}
+
+func (d *differ) diff(path []string, a, b *Value) {
+	if a == nil || b == nil {
+		if a != nil {
+			d.add(ChangeRemoved, path, a, nil)
+		} else if b != nil {
+			d.add(ChangeAdded, path, nil, b)
+		}
+		return
+	}
+	ta, tb := a.vType(), b.vType()
+	if ta == TypeObject && tb == TypeObject {
+		a.o.unescapeKeys()
+		b.o.unescapeKeys()
+		for _, kv := range a.o.kvs {
+			p := append(path, kv.k)
+			bv := b.o.Get(kv.k)
+			if bv == nil {
+				d.add(ChangeRemoved, p, kv.v, nil)
+				continue
+			}
+			d.diff(p, kv.v, bv)
+		}
+		for _, kv := range b.o.kvs {
+			if a.o.Get(kv.k) == nil {
+				d.add(ChangeAdded, append(path, kv.k), nil, kv.v)
+			}
+		}
+		return
+	}
+	if ta == TypeArray && tb == TypeArray {
+		n := len(a.a)
+		if len(b.a) < n {
+			n = len(b.a)
+		}
+		for i := 0; i < n; i++ {
+			d.diff(append(path, strconv.Itoa(i)), a.a[i], b.a[i])
+		}
+		for i := n; i < len(b.a); i++ {
+			d.add(ChangeAdded, append(path, strconv.Itoa(i)), nil, b.a[i])
+		}
+		for i := len(a.a) - 1; i >= n; i-- {
+			d.add(ChangeRemoved, append(path, strconv.Itoa(i)), a.a[i], nil)
+		}
+		return
+	}
+	if !EqualWith(a, b, diffOptions) {
+		d.add(ChangeModified, path, a, b)
+	}
+}
@@ -0,0 +1,386 @@
+package jsonpart
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffreyzj/jsonpart/internal/jsscan"
+)
+
+// EmbeddedKind is the origin of JSON value found by Discover.
+type EmbeddedKind int
+
+const (
+	// EmbeddedScript is the whole body of <script> tag such as
+	// <script type="application/ld+json">.
+	EmbeddedScript EmbeddedKind = iota
+
+	// EmbeddedAssignment is the value assigned to JavaScript variable
+	// or property such as window.__STATE__ = {...}.
+	EmbeddedAssignment
+
+	// EmbeddedJSONParse is JavaScript string literal passed to JSON.parse.
+	EmbeddedJSONParse
+
+	// EmbeddedDataAttribute is the value of HTML data-* attribute.
+	EmbeddedDataAttribute
+)
+
+// String returns string representation of k.
+func (k EmbeddedKind) String() string {
+	switch k {
+	case EmbeddedScript:
+		return "script"
+	case EmbeddedAssignment:
+		return "assignment"
+	case EmbeddedJSONParse:
+		return "JSON.parse"
+	case EmbeddedDataAttribute:
+		return "data-attribute"
+	default:
+		panic(fmt.Errorf("BUG: unknown EmbeddedKind: %d", k))
+	}
+}
+
+// Embedded is JSON object or array found by Discover.
+type Embedded struct {
+	// Offset is the byte offset of the value text in the document.
+	//
+	// For EmbeddedJSONParse and EmbeddedDataAttribute it is the offset
+	// of the encoded string literal or attribute contents without quotes.
+	Offset int
+
+	// Size is the byte size of the value text in the document.
+	Size int
+
+	// Kind is the origin of the value.
+	Kind EmbeddedKind
+
+	// Name is the variable name for EmbeddedAssignment and for EmbeddedJSONParse
+	// results assigned to a variable, the attribute name
+	// for EmbeddedDataAttribute and the id attribute for EmbeddedScript.
+	// It is empty if unknown.
+	Name string
+
+	// Keys contains top-level object keys in their original order.
+	// It is nil for arrays.
+	Keys []string
+
+	// Value is the parsed value.
+	//
+	// Value.Offset refers to the document for EmbeddedScript and
+	// EmbeddedAssignment and to the decoded text for other kinds.
+	Value *Value
+}
+
+// Discover finds all the JSON objects and arrays embedded in document s.
+//
+// It looks for <script> bodies, JavaScript assignments, JSON.parse string
+// literals and HTML data-* attributes. Text, which isn't valid JSON,
+// such as JavaScript object literals with unquoted keys, is skipped.
+// Values nested into other found values aren't returned.
+//
+// The returned values are sorted by Offset. Use Embedded.Keys for choosing
+// partialKey for Parse.
+func Discover(s string) []Embedded {
+	var es []Embedded
+	es = discoverScripts(es, s)
+	es = discoverAssignments(es, s)
+	es = discoverJSONParse(es, s)
+	es = discoverDataAttributes(es, s)
+
+	sort.SliceStable(es, func(i, j int) bool {
+		if es[i].Offset != es[j].Offset {
+			return es[i].Offset < es[j].Offset
+		}
+		return es[i].Size > es[j].Size
+	})
+	// Drop values nested into previous ones, e.g. assignments found
+	// inside JSON strings.
+	result := es[:0]
+	end := -1
+	for _, e := range es {
+		if e.Offset+e.Size <= end {
+			continue
+		}
+		result = append(result, e)
+		end = e.Offset + e.Size
+	}
+	return result
+}
+
+var (
+	discoverJSONParseRe = regexp.MustCompile(`JSON\s*\.\s*parse\s*\(\s*['"` + "`" + `]`)
+
+	// discoverAssignedNameRe matches the variable name in `name = JSON.parse(...)`.
+	discoverAssignedNameRe = regexp.MustCompile(`([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*)\s*=\s*$`)
+
+	discoverDataRe = regexp.MustCompile(`(?is)\s(data-[\w.:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+func discoverScripts(es []Embedded, s string) []Embedded {
+	jsscan.VisitScripts(s, func(id string, start, end int) bool {
+		// The body mustn't contain JavaScript code after the value.
+		if v := discoverParseWhole(s[start:end], start); v != nil {
+			es = appendEmbedded(es, EmbeddedScript, id, v, -1, 0)
+		}
+		return true
+	})
+	return es
+}
+
+func discoverAssignments(es []Embedded, s string) []Embedded {
+	jsscan.VisitAssignments(s, func(name string, start int) bool {
+		if v := discoverParse(s[start:], start); v != nil {
+			es = appendEmbedded(es, EmbeddedAssignment, name, v, -1, 0)
+		}
+		return true
+	})
+	return es
+}
+
+func discoverJSONParse(es []Embedded, s string) []Embedded {
+	for _, m := range discoverJSONParseRe.FindAllStringIndex(s, -1) {
+		start := m[1]
+		decoded, n, ok := unquoteJSString(s[start-1:])
+		if !ok {
+			continue
+		}
+		v := discoverParseWhole(strings.TrimSpace(decoded), 0)
+		if v == nil {
+			continue
+		}
+		var name string
+		if nm := discoverAssignedNameRe.FindStringSubmatch(s[maxNameLookbehind(m[0]):m[0]]); nm != nil {
+			name = nm[1]
+		}
+		// n includes both quotes.
+		es = appendEmbedded(es, EmbeddedJSONParse, name, v, start, n-2)
+	}
+	return es
+}
+
+// maxNameLookbehind returns the start of the text before offset,
+// which may contain the assigned variable name.
+func maxNameLookbehind(offset int) int {
+	const n = 256
+	if offset < n {
+		return 0
+	}
+	return offset - n
+}
+
+func discoverDataAttributes(es []Embedded, s string) []Embedded {
+	for _, m := range discoverDataRe.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[4], m[5]
+		if start < 0 {
+			start, end = m[6], m[7]
+		}
+		decoded := strings.TrimSpace(html.UnescapeString(s[start:end]))
+		v := discoverParseWhole(decoded, 0)
+		if v == nil {
+			continue
+		}
+		es = appendEmbedded(es, EmbeddedDataAttribute, s[m[2]:m[3]], v, start, end-start)
+	}
+	return es
+}
+
+// discoverParse parses object or array at the start of s, which starts
+// at the offset base in the document.
+//
+// nil is returned if s doesn't start with valid JSON object or array.
+func discoverParse(s string, base int) *Value {
+	if len(s) == 0 || (s[0] != '{' && s[0] != '[') {
+		return nil
+	}
+	v, err := parsePrefix(s, base)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// discoverParseWhole is like discoverParse, but returns nil if s contains
+// anything after the value.
+func discoverParseWhole(s string, base int) *Value {
+	v := discoverParse(s, base)
+	if v == nil {
+		return nil
+	}
+	if _, end := v.Offset(); end-base != len(s) {
+		return nil
+	}
+	return v
+}
+
+// appendEmbedded appends v to es.
+//
+// The value offsets are used if offset is negative.
+func appendEmbedded(es []Embedded, kind EmbeddedKind, name string, v *Value, offset, size int) []Embedded {
+	if offset < 0 {
+		start, end := v.Offset()
+		offset, size = start, end-start
+	}
+	var keys []string
+	if v.Type() == TypeObject {
+		o, _ := v.Object()
+		keys = make([]string, 0, o.Len())
+		o.Visit(func(k []byte, _ *Value) {
+			keys = append(keys, string(k))
+		})
+	}
+	return append(es, Embedded{
+		Offset: offset,
+		Size:   size,
+		Kind:   kind,
+		Name:   name,
+		Keys:   keys,
+		Value:  v,
+	})
+}
+
+// parsePrefix parses JSON value at the start of s, which starts at the offset
+// base in the document. The text after the value is ignored.
+//
+// Only the value text is copied, so the returned value doesn't retain
+// the rest of s.
+func parsePrefix(s string, base int) (*Value, error) {
+	// Find the end of the value without copying s, since s may contain
+	// the rest of a big document with many values.
+	var c cache
+	_, tail, err := parseValue(skipWS(s), &c, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse JSON: %s; unparsed tail: %q", err, startEndString(tail))
+	}
+	p := &parser{}
+	v, err := p.parse(s[:len(s)-len(tail)], base)
+	if err != nil {
+		panic(fmt.Errorf("BUG: cannot parse already parsed value: %w", err))
+	}
+	return v, nil
+}
+
+// unquoteJSString decodes JavaScript string literal at the start of s.
+//
+// It returns the decoded string and the literal length including quotes.
+// ok is false if s doesn't start with valid string literal.
+func unquoteJSString(s string) (decoded string, n int, ok bool) {
+	if len(s) == 0 {
+		return "", 0, false
+	}
+	quote := s[0]
+	if quote != '"' && quote != '\'' && quote != '`' {
+		return "", 0, false
+	}
+	var b strings.Builder
+	i := 1
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, true
+		case quote == '`' && c == '$' && i+1 < len(s) && s[i+1] == '{':
+			// Template literals with substitutions cannot be decoded statically.
+			return "", 0, false
+		case (c == '\n' || c == '\r') && quote != '`':
+			return "", 0, false
+		case c != '\\':
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		// Escape sequence.
+		i++
+		if i >= len(s) {
+			return "", 0, false
+		}
+		c = s[i]
+		i++
+		switch c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+			// Line continuation.
+		case '\r':
+			if i < len(s) && s[i] == '\n' {
+				i++
+			}
+		case 'x':
+			if i+2 > len(s) {
+				return "", 0, false
+			}
+			x, err := strconv.ParseUint(s[i:i+2], 16, 8)
+			if err != nil {
+				return "", 0, false
+			}
+			b.WriteRune(rune(x))
+			i += 2
+		case 'u':
+			r, size := decodeJSUnicodeEscape(s[i:])
+			if size == 0 {
+				return "", 0, false
+			}
+			i += size
+			// Combine surrogate pairs.
+			if r >= 0xd800 && r < 0xdc00 && strings.HasPrefix(s[i:], `\u`) {
+				r2, size2 := decodeJSUnicodeEscape(s[i+2:])
+				if size2 > 0 && r2 >= 0xdc00 && r2 < 0xe000 {
+					r = (r-0xd800)<<10 + (r2 - 0xdc00) + 0x10000
+					i += 2 + size2
+				}
+			}
+			if !utf8.ValidRune(r) {
+				r = utf8.RuneError
+			}
+			b.WriteRune(r)
+		default:
+			// Unknown escapes such as \' and \/ stand for the character itself.
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, false
+}
+
+// decodeJSUnicodeEscape decodes the part of \uXXXX or \u{X...} escape after \u.
+//
+// It returns the decoded rune and the number of consumed bytes.
+// Zero size is returned for invalid escape.
+func decodeJSUnicodeEscape(s string) (rune, int) {
+	if strings.HasPrefix(s, "{") {
+		n := strings.IndexByte(s, '}')
+		if n < 2 {
+			return 0, 0
+		}
+		x, err := strconv.ParseUint(s[1:n], 16, 32)
+		if err != nil || x > utf8.MaxRune {
+			return 0, 0
+		}
+		return rune(x), n + 1
+	}
+	if len(s) < 4 {
+		return 0, 0
+	}
+	x, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, 0
+	}
+	return rune(x), 4
+}
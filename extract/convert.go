@@ -0,0 +1,83 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// converter converts non-null v to field value.
+type converter func(v *jsonpart.Value) (any, error)
+
+func newConverter(typ string, layouts []string) (converter, error) {
+	if elem := strings.TrimPrefix(typ, "[]"); elem != typ {
+		conv, err := newConverter(elem, layouts)
+		if err != nil {
+			return nil, err
+		}
+		return func(v *jsonpart.Value) (any, error) {
+			a, err := v.Array()
+			if err != nil {
+				return nil, err
+			}
+			xs := make([]any, len(a))
+			for i, item := range a {
+				if item.Type() == jsonpart.TypeNull {
+					continue
+				}
+				x, err := conv(item)
+				if err != nil {
+					return nil, fmt.Errorf("array item #%d: %w", i, err)
+				}
+				xs[i] = x
+			}
+			return xs, nil
+		}, nil
+	}
+
+	switch typ {
+	case "", "any":
+		return func(v *jsonpart.Value) (any, error) {
+			return v.Interface(), nil
+		}, nil
+	case "string":
+		return func(v *jsonpart.Value) (any, error) {
+			s, _, err := v.Coerce().String()
+			return s, err
+		}, nil
+	case "int":
+		return func(v *jsonpart.Value) (any, error) {
+			n, _, err := v.Coerce().Int64()
+			return n, err
+		}, nil
+	case "float":
+		return func(v *jsonpart.Value) (any, error) {
+			f, _, err := v.Coerce().Float64()
+			return f, err
+		}, nil
+	case "bool":
+		return func(v *jsonpart.Value) (any, error) {
+			b, _, err := v.Coerce().Bool()
+			return b, err
+		}, nil
+	case "time":
+		return func(v *jsonpart.Value) (any, error) {
+			return v.Time(layouts...)
+		}, nil
+	case "unix_time":
+		return func(v *jsonpart.Value) (any, error) {
+			return v.UnixTime()
+		}, nil
+	case "unix_milli":
+		return func(v *jsonpart.Value) (any, error) {
+			return v.UnixMilli()
+		}, nil
+	case "duration":
+		return func(v *jsonpart.Value) (any, error) {
+			return v.Duration()
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+}
@@ -0,0 +1,319 @@
+// Package extract extracts records from pages according to declarative rules.
+//
+// Rules are usually loaded from JSON or YAML files with Load or LoadFile,
+// so new sites may be added without writing Go code. Example YAML rule:
+//
+//	# rules.yaml
+//	- name: shop
+//	  locate:
+//	    script_id: __NEXT_DATA__
+//	    key: product
+//	  fields:
+//	    - name: title
+//	      path: name
+//	      type: string
+//	      required: true
+//	    - name: price
+//	      path: offers.0.price
+//	      type: float
+//	    - name: tags
+//	      path: /tags
+//	      type: "[]string"
+//	      default: []
+//	    - name: published
+//	      path: meta.date
+//	      type: time
+//	      layouts: ["2006-01-02"]
+//
+// Supported field types: any, string, int, float, bool, time, unix_time,
+// unix_milli, duration and arrays of them such as []string.
+// Numbers and booleans stored in strings are accepted for int, float and bool,
+// and numbers are accepted for string. See jsonpart.Coercer for details.
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// Rule describes how to extract a record from a page.
+type Rule struct {
+	// Name is the rule name, usually the site name.
+	Name string `json:"name" yaml:"name"`
+
+	// Locate locates the JSON value in the page.
+	Locate Locator `json:"locate" yaml:"locate"`
+
+	// Fields lists the record fields.
+	Fields []Field `json:"fields" yaml:"fields"`
+}
+
+// Locator locates JSON value in a page.
+//
+// Non-empty locator parts are applied in the following order:
+// ScriptID narrows the page to the <script> body with the given id,
+// Var narrows it to the value assigned to the given JavaScript variable
+// and Key locates the value by partial key inside the narrowed text.
+// The whole narrowed text is parsed as JSON if Key is empty.
+type Locator struct {
+	// ScriptID is the id attribute of the <script> tag containing the value.
+	ScriptID string `json:"script_id,omitempty" yaml:"script_id,omitempty"`
+
+	// Var is the JavaScript variable name such as ctx or window.__STATE__.
+	// Bracketed properties such as window["__STATE__"] match dotted ones.
+	Var string `json:"var,omitempty" yaml:"var,omitempty"`
+
+	// Key is the partial key. See jsonpart.Parse.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+// Field describes a single record field.
+type Field struct {
+	// Name is the field name in the record.
+	Name string `json:"name" yaml:"name"`
+
+	// Path is dot-separated keys path inside the located value such as
+	// items.0.name, or JSON pointer such as /items/0/name.
+	// The located value itself is used if Path is empty.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Type is the field type. The value is returned as is if Type is empty.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Layouts contains time layouts for time fields.
+	// See jsonpart.Value.Time for the default layouts.
+	Layouts []string `json:"layouts,omitempty" yaml:"layouts,omitempty"`
+
+	// Default is the value for missing fields. It must have the field type.
+	Default any `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Required makes missing field an error.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Extractor extracts records according to a compiled rule.
+//
+// Extractor may be used from concurrent goroutines.
+type Extractor struct {
+	name   string
+	loc    *locator
+	fields []*field
+}
+
+type field struct {
+	name     string
+	path     []string
+	conv     converter
+	required bool
+
+	// def is the frozen default value. It is converted for every Result,
+	// so results don't share mutable default values such as slices.
+	def *jsonpart.Value
+}
+
+// Compile compiles r.
+func Compile(r Rule) (*Extractor, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("missing rule name")
+	}
+	e := &Extractor{
+		name: r.Name,
+		loc:  compileLocator(r.Locate),
+	}
+	seen := make(map[string]bool, len(r.Fields))
+	for i := range r.Fields {
+		f, err := compileField(&r.Fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+		if seen[f.name] {
+			return nil, fmt.Errorf("rule %q: duplicate field %q", r.Name, f.name)
+		}
+		seen[f.name] = true
+		e.fields = append(e.fields, f)
+	}
+	return e, nil
+}
+
+func compileField(rf *Field) (*field, error) {
+	if rf.Name == "" {
+		return nil, fmt.Errorf("missing field name")
+	}
+	path, err := parsePath(rf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("field %q: %w", rf.Name, err)
+	}
+	conv, err := newConverter(rf.Type, rf.Layouts)
+	if err != nil {
+		return nil, fmt.Errorf("field %q: %w", rf.Name, err)
+	}
+	f := &field{
+		name:     rf.Name,
+		path:     path,
+		conv:     conv,
+		required: rf.Required,
+	}
+	if rf.Default != nil {
+		if rf.Required {
+			return nil, fmt.Errorf("field %q: required field cannot have default", rf.Name)
+		}
+		v, err := jsonpart.FromInterface(rf.Default)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: invalid default: %w", rf.Name, err)
+		}
+		if _, err := conv(v); err != nil {
+			return nil, fmt.Errorf("field %q: invalid default: %w", rf.Name, err)
+		}
+		// The default is read from concurrent Extract calls.
+		v.Freeze()
+		f.def = v
+	}
+	return f, nil
+}
+
+// defaultValue returns new copy of the field default.
+func (f *field) defaultValue() any {
+	x, err := f.conv(f.def)
+	if err != nil {
+		panic(fmt.Errorf("BUG: cannot convert already validated default: %w", err))
+	}
+	return x
+}
+
+// parsePath parses dot-separated keys path or JSON pointer.
+func parsePath(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(p, "/") {
+		keys, err := jsonpart.ParsePointer(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path: %w", err)
+		}
+		return keys, nil
+	}
+	return strings.Split(p, "."), nil
+}
+
+// Name returns the rule name.
+func (e *Extractor) Name() string {
+	return e.name
+}
+
+// Extract extracts a record from page.
+//
+// An error is returned if the JSON value cannot be located or parsed.
+// Field errors are recorded in the returned Result.
+func (e *Extractor) Extract(page string) (*Result, error) {
+	v, err := e.loc.locate(page)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", e.name, err)
+	}
+	return e.ExtractValue(v), nil
+}
+
+// ExtractBytes extracts a record from page.
+func (e *Extractor) ExtractBytes(page []byte) (*Result, error) {
+	return e.Extract(string(page))
+}
+
+// ExtractValue extracts a record from the already located value v.
+func (e *Extractor) ExtractValue(v *jsonpart.Value) *Result {
+	r := &Result{
+		Values: make(map[string]any, len(e.fields)),
+	}
+	for _, f := range e.fields {
+		fv := v.Get(f.path...)
+		if fv == nil || fv.Type() == jsonpart.TypeNull {
+			switch {
+			case f.required:
+				r.record(f, jsonpart.ErrNotFound)
+			case f.def != nil:
+				r.Values[f.name] = f.defaultValue()
+			}
+			continue
+		}
+		x, err := f.conv(fv)
+		if err != nil {
+			r.record(f, err)
+			if f.def != nil {
+				r.Values[f.name] = f.defaultValue()
+			}
+			continue
+		}
+		r.Values[f.name] = x
+	}
+	return r
+}
+
+// Result is an extracted record.
+type Result struct {
+	// Values maps field names to field values.
+	//
+	// Missing optional fields without default are absent.
+	// JSON null is treated as missing value.
+	Values map[string]any
+
+	// Errors contains field errors in the order of rule fields.
+	Errors []*FieldError
+}
+
+func (r *Result) record(f *field, err error) {
+	r.Errors = append(r.Errors, &FieldError{
+		Field: f.name,
+		Path:  f.path,
+		Err:   err,
+	})
+}
+
+// Err returns the first field error.
+//
+// nil is returned if all the fields have been extracted.
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[0]
+}
+
+// Decode decodes r.Values into dst via encoding/json.
+//
+// dst fields are matched by json tags, so dst may be a struct pointer.
+// Field errors aren't returned by Decode; check Err for them.
+func (r *Result) Decode(dst any) error {
+	data, err := json.Marshal(r.Values)
+	if err != nil {
+		return fmt.Errorf("cannot marshal extracted values: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("cannot decode extracted values: %w", err)
+	}
+	return nil
+}
+
+// FieldError records an error for the given record field.
+type FieldError struct {
+	// Field is the field name.
+	Field string
+
+	// Path is the keys path of the field value.
+	Path []string
+
+	// Err is the underlying error.
+	//
+	// It is jsonpart.ErrNotFound for missing required fields.
+	Err error
+}
+
+// Error implements error interface.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("cannot extract field %q at %q: %s", e.Field, jsonpart.FormatPointer(e.Path), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,71 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load loads and compiles rules from data.
+//
+// data must contain a list of rules in JSON or YAML format.
+// It is parsed as JSON if it starts with '['. Unknown rule properties
+// and duplicate rule names are rejected, so typos are caught early.
+func Load(data []byte) ([]*Extractor, error) {
+	rules, err := parseRules(data)
+	if err != nil {
+		return nil, err
+	}
+	es := make([]*Extractor, 0, len(rules))
+	seen := make(map[string]bool, len(rules))
+	for i := range rules {
+		e, err := Compile(rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile rule #%d: %w", i, err)
+		}
+		if seen[e.name] {
+			return nil, fmt.Errorf("duplicate rule %q", e.name)
+		}
+		seen[e.name] = true
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+// LoadFile loads and compiles rules from the file at path.
+//
+// See Load for the file format.
+func LoadFile(path string) ([]*Extractor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rules: %w", err)
+	}
+	es, err := Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load rules from %q: %w", path, err)
+	}
+	return es, nil
+}
+
+func parseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.DisallowUnknownFields()
+		// Keep numeric defaults precise.
+		d.UseNumber()
+		if err := d.Decode(&rules); err != nil {
+			return nil, fmt.Errorf("cannot parse JSON rules: %w", err)
+		}
+		return rules, nil
+	}
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	d.KnownFields(true)
+	if err := d.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("cannot parse YAML rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"fmt"
+
+	"github.com/jeffreyzj/jsonpart"
+	"github.com/jeffreyzj/jsonpart/internal/jsscan"
+)
+
+// locator is a compiled Locator.
+type locator struct {
+	scriptID string
+	varName  string
+	key      string
+}
+
+func compileLocator(l Locator) *locator {
+	return &locator{
+		scriptID: l.ScriptID,
+		varName:  l.Var,
+		key:      l.Key,
+	}
+}
+
+// locate locates JSON value in page.
+func (l *locator) locate(page string) (*jsonpart.Value, error) {
+	s := page
+	if l.scriptID != "" {
+		body, ok := jsscan.ScriptBody(s, l.scriptID)
+		if !ok {
+			return nil, fmt.Errorf("cannot find <script> with id %q", l.scriptID)
+		}
+		s = body
+	}
+	if l.varName != "" {
+		v, err := l.varValue(s)
+		if err != nil {
+			return nil, err
+		}
+		if l.key == "" {
+			return v, nil
+		}
+		// Search for the partial key only inside the assigned value.
+		s = string(v.Raw())
+	}
+	return jsonpart.Parse(s, l.key)
+}
+
+// varValue returns the value assigned to the variable in s.
+//
+// Assignments such as `var name = ...`, `name = ...`, `window.name = ...`
+// and `window["name"] = ...` are recognized. The first assignment with valid JSON value is returned.
+func (l *locator) varValue(s string) (*jsonpart.Value, error) {
+	name := jsscan.CanonicalName(l.varName)
+	var v *jsonpart.Value
+	var lastErr error
+	jsscan.VisitAssignments(s, func(assigned string, start int) bool {
+		if jsscan.CanonicalName(assigned) != name {
+			return true
+		}
+		var err error
+		v, err = jsonpart.Parse(s[start:])
+		if err != nil {
+			// The variable may be re-assigned with JSON value later.
+			lastErr = err
+			return true
+		}
+		return false
+	})
+	if v != nil {
+		return v, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("cannot parse value of variable %q: %w", l.varName, lastErr)
+	}
+	return nil, fmt.Errorf("cannot find assignment to variable %q", l.varName)
+}
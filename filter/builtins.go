@@ -0,0 +1,774 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// builtinFunc evaluates builtin function with the given args for the input in.
+//
+// args are unevaluated, so functions like select and map may evaluate them
+// for other inputs.
+type builtinFunc func(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error)
+
+// builtins maps "name/arity" to builtin functions.
+var builtins = map[string]builtinFunc{
+	"empty/0":          builtinEmpty,
+	"not/0":            builtinNot,
+	"select/1":         builtinSelect,
+	"map/1":            builtinMap,
+	"map_values/1":     builtinMapValues,
+	"keys/0":           builtinKeys,
+	"keys_unsorted/0":  builtinKeysUnsorted,
+	"has/1":            builtinHas,
+	"length/0":         builtinLength,
+	"type/0":           builtinType,
+	"add/0":            builtinAdd,
+	"any/0":            builtinAny,
+	"all/0":            builtinAll,
+	"first/0":          builtinFirst,
+	"last/0":           builtinLast,
+	"first/1":          builtinFirstOf,
+	"last/1":           builtinLastOf,
+	"limit/2":          builtinLimit,
+	"range/1":          builtinRange,
+	"reverse/0":        builtinReverse,
+	"sort/0":           builtinSort,
+	"sort_by/1":        builtinSortBy,
+	"unique/0":         builtinUnique,
+	"min/0":            builtinMin,
+	"max/0":            builtinMax,
+	"floor/0":          builtinFloor,
+	"ceil/0":           builtinCeil,
+	"tostring/0":       builtinToString,
+	"tonumber/0":       builtinToNumber,
+	"tojson/0":         builtinToJSON,
+	"fromjson/0":       builtinFromJSON,
+	"ascii_downcase/0": builtinASCIIDowncase,
+	"ascii_upcase/0":   builtinASCIIUpcase,
+	"ltrimstr/1":       builtinLtrimstr,
+	"rtrimstr/1":       builtinRtrimstr,
+	"startswith/1":     builtinStartsWith,
+	"endswith/1":       builtinEndsWith,
+	"split/1":          builtinSplit,
+	"join/1":           builtinJoin,
+	"test/1":           builtinTest,
+	"contains/1":       builtinContains,
+	"to_entries/0":     builtinToEntries,
+	"from_entries/0":   builtinFromEntries,
+	"with_entries/1":   builtinWithEntries,
+	"values/0":         builtinValues,
+	"recurse/0":        builtinRecurse,
+}
+
+func lookupBuiltin(name string, arity int) builtinFunc {
+	return builtins[name+"/"+strconv.Itoa(arity)]
+}
+
+func single(v *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{v}, nil
+}
+
+// evalString evaluates arg, which must produce a single string.
+func evalString(e *env, in *jsonpart.Value, arg node) (string, error) {
+	vs, err := arg.eval(e, in)
+	if err != nil {
+		return "", err
+	}
+	if len(vs) != 1 || vs[0].Type() != jsonpart.TypeString {
+		return "", fmt.Errorf("argument must be a single string")
+	}
+	return stringOf(vs[0]), nil
+}
+
+func inputString(in *jsonpart.Value) (string, error) {
+	if in.Type() != jsonpart.TypeString {
+		return "", fmt.Errorf("cannot be applied to %s; it must be string", typeName(in))
+	}
+	return stringOf(in), nil
+}
+
+func inputArray(in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	if in.Type() != jsonpart.TypeArray {
+		return nil, fmt.Errorf("cannot be applied to %s; it must be array", typeName(in))
+	}
+	return in.GetArray(), nil
+}
+
+func builtinEmpty(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return nil, nil
+}
+
+func builtinNot(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return single(e.newBool(!isTruthy(in)))
+}
+
+func builtinSelect(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	cs, err := args[0].eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, c := range cs {
+		if isTruthy(c) {
+			out = append(out, in)
+		}
+	}
+	return out, nil
+}
+
+func builtinMap(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := iterate(in)
+	if err != nil {
+		return nil, err
+	}
+	a := e.a.NewArray()
+	for _, item := range items {
+		vs, err := args[0].eval(e, item)
+		if err != nil {
+			return nil, err
+		}
+		a.Append(vs...)
+	}
+	return single(a)
+}
+
+func builtinMapValues(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	switch in.Type() {
+	case jsonpart.TypeArray:
+		a := e.a.NewArray()
+		for _, item := range in.GetArray() {
+			vs, err := args[0].eval(e, item)
+			if err != nil {
+				return nil, err
+			}
+			if len(vs) > 0 {
+				a.Append(vs[0])
+			}
+		}
+		return single(a)
+	case jsonpart.TypeObject:
+		o := e.a.NewObject()
+		var err error
+		in.GetObject().Visit(func(k []byte, v *jsonpart.Value) {
+			if err != nil {
+				return
+			}
+			var vs []*jsonpart.Value
+			vs, err = args[0].eval(e, v)
+			if err == nil && len(vs) > 0 {
+				o.Set(string(k), vs[0])
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return single(o)
+	default:
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(in))
+	}
+}
+
+func builtinKeys(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() == jsonpart.TypeObject {
+		a := e.a.NewArray()
+		for _, k := range sortedKeys(in) {
+			a.Append(e.a.NewString(k))
+		}
+		return single(a)
+	}
+	return builtinKeysUnsorted(e, in, args)
+}
+
+func builtinKeysUnsorted(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	a := e.a.NewArray()
+	switch in.Type() {
+	case jsonpart.TypeObject:
+		in.GetObject().Visit(func(k []byte, _ *jsonpart.Value) {
+			a.Append(e.a.NewString(string(k)))
+		})
+	case jsonpart.TypeArray:
+		for i := range in.GetArray() {
+			a.Append(e.a.NewNumberInt(i))
+		}
+	default:
+		return nil, fmt.Errorf("%s has no keys", typeName(in))
+	}
+	return single(a)
+}
+
+func builtinHas(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	ks, err := args[0].eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, k := range ks {
+		switch {
+		case in.Type() == jsonpart.TypeObject && k.Type() == jsonpart.TypeString:
+			out = append(out, e.newBool(in.GetObject().Get(stringOf(k)) != nil))
+		case in.Type() == jsonpart.TypeArray && k.Type() == jsonpart.TypeNumber:
+			i := floatOf(k)
+			out = append(out, e.newBool(i >= 0 && i < float64(len(in.GetArray()))))
+		default:
+			return nil, fmt.Errorf("cannot check whether %s has %s key", typeName(in), typeName(k))
+		}
+	}
+	return out, nil
+}
+
+func builtinLength(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	switch in.Type() {
+	case jsonpart.TypeNull:
+		return single(e.a.NewNumberInt(0))
+	case jsonpart.TypeString:
+		return single(e.a.NewNumberInt(utf8.RuneCountInString(stringOf(in))))
+	case jsonpart.TypeArray:
+		return single(e.a.NewNumberInt(len(in.GetArray())))
+	case jsonpart.TypeObject:
+		return single(e.a.NewNumberInt(in.GetObject().Len()))
+	case jsonpart.TypeNumber:
+		return single(e.newNumber(math.Abs(floatOf(in))))
+	default:
+		return nil, fmt.Errorf("%s has no length", typeName(in))
+	}
+}
+
+func builtinType(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return single(e.a.NewString(typeName(in)))
+}
+
+func builtinAdd(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := iterate(in)
+	if err != nil {
+		return nil, err
+	}
+	sum := e.a.NewNull()
+	for _, item := range items {
+		sum, err = e.binary("+", sum, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return single(sum)
+}
+
+func builtinAny(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := iterate(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if isTruthy(item) {
+			return single(e.newBool(true))
+		}
+	}
+	return single(e.newBool(false))
+}
+
+func builtinAll(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := iterate(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if !isTruthy(item) {
+			return single(e.newBool(false))
+		}
+	}
+	return single(e.newBool(true))
+}
+
+func builtinFirst(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	v, err := e.index(in, e.a.NewNumberInt(0))
+	if err != nil {
+		return nil, err
+	}
+	return single(v)
+}
+
+func builtinLast(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	v, err := e.index(in, e.a.NewNumberInt(-1))
+	if err != nil {
+		return nil, err
+	}
+	return single(v)
+}
+
+func builtinFirstOf(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	vs, err := args[0].eval(e, in)
+	if len(vs) > 0 {
+		// jq stops at the first output, so later errors are ignored.
+		return vs[:1], nil
+	}
+	return nil, err
+}
+
+func builtinLastOf(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	vs, err := args[0].eval(e, in)
+	if err != nil || len(vs) == 0 {
+		return nil, err
+	}
+	return vs[len(vs)-1:], nil
+}
+
+func builtinLimit(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	ns, err := args[0].eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	if len(ns) != 1 || ns[0].Type() != jsonpart.TypeNumber {
+		return nil, fmt.Errorf("limit must be a single number")
+	}
+	n := int(floatOf(ns[0]))
+	if n <= 0 {
+		return nil, nil
+	}
+	vs, err := args[1].eval(e, in)
+	if len(vs) >= n {
+		// jq stops after n outputs, so later errors are ignored.
+		return vs[:n], nil
+	}
+	return vs, err
+}
+
+// maxRange limits the number of outputs of range(n).
+const maxRange = 1e6
+
+func builtinRange(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	ns, err := args[0].eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, n := range ns {
+		if n.Type() != jsonpart.TypeNumber {
+			return nil, fmt.Errorf("range must be a number; got %s", typeName(n))
+		}
+		f := floatOf(n)
+		if f > maxRange {
+			return nil, fmt.Errorf("range %s exceeds %d", n.MarshalString(), int(maxRange))
+		}
+		for i := 0; float64(i) < f; i++ {
+			out = append(out, e.a.NewNumberInt(i))
+		}
+	}
+	return out, nil
+}
+
+func builtinReverse(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() == jsonpart.TypeString {
+		rs := []rune(stringOf(in))
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+		return single(e.a.NewString(string(rs)))
+	}
+	if in.Type() == jsonpart.TypeNull {
+		return single(e.a.NewArray())
+	}
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	a := e.a.NewArray()
+	for i := len(items) - 1; i >= 0; i-- {
+		a.Append(items[i])
+	}
+	return single(a)
+}
+
+func builtinSort(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]*jsonpart.Value(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compare(sorted[i], sorted[j]) < 0
+	})
+	a := e.a.NewArray()
+	a.Append(sorted...)
+	return single(a)
+}
+
+func builtinSortBy(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	type pair struct {
+		key  *jsonpart.Value
+		item *jsonpart.Value
+	}
+	pairs := make([]pair, len(items))
+	for i, item := range items {
+		ks, err := args[0].eval(e, item)
+		if err != nil {
+			return nil, err
+		}
+		// Multiple outputs are compared as an array like in jq.
+		key := e.a.NewArray()
+		key.Append(ks...)
+		pairs[i] = pair{key: key, item: item}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return compare(pairs[i].key, pairs[j].key) < 0
+	})
+	a := e.a.NewArray()
+	for _, p := range pairs {
+		a.Append(p.item)
+	}
+	return single(a)
+}
+
+func builtinUnique(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	vs, err := builtinSort(e, in, args)
+	if err != nil {
+		return nil, err
+	}
+	a := e.a.NewArray()
+	var prev *jsonpart.Value
+	for _, item := range vs[0].GetArray() {
+		if prev == nil || compare(prev, item) != 0 {
+			a.Append(item)
+		}
+		prev = item
+	}
+	return single(a)
+}
+
+func builtinMin(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return extremum(e, in, -1)
+}
+
+func builtinMax(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return extremum(e, in, 1)
+}
+
+// extremum returns the minimum item of in array if sign is -1 and the maximum one if sign is 1.
+func extremum(e *env, in *jsonpart.Value, sign int) ([]*jsonpart.Value, error) {
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return single(e.a.NewNull())
+	}
+	best := items[0]
+	for _, item := range items[1:] {
+		if compare(item, best)*sign >= 0 {
+			best = item
+		}
+	}
+	return single(best)
+}
+
+func builtinFloor(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() != jsonpart.TypeNumber {
+		return nil, fmt.Errorf("%s number required", typeName(in))
+	}
+	return single(e.newNumber(math.Floor(floatOf(in))))
+}
+
+func builtinCeil(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() != jsonpart.TypeNumber {
+		return nil, fmt.Errorf("%s number required", typeName(in))
+	}
+	return single(e.newNumber(math.Ceil(floatOf(in))))
+}
+
+func builtinToString(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() == jsonpart.TypeString {
+		return single(in)
+	}
+	return single(e.a.NewString(in.MarshalString()))
+}
+
+func builtinToNumber(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	switch in.Type() {
+	case jsonpart.TypeNumber:
+		return single(in)
+	case jsonpart.TypeString:
+		s := strings.TrimSpace(stringOf(in))
+		if _, err := json.Number(s).Float64(); err != nil || !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("cannot parse %q as number", s)
+		}
+		return single(e.a.NewNumberString(s))
+	default:
+		return nil, fmt.Errorf("%s cannot be parsed as number", typeName(in))
+	}
+}
+
+func builtinToJSON(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return single(e.a.NewString(in.MarshalString()))
+}
+
+func builtinFromJSON(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	s, err := inputString(in)
+	if err != nil {
+		return nil, err
+	}
+	v, err := jsonpart.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return single(v)
+}
+
+func builtinASCIIDowncase(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return mapString(e, in, func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'A' && r <= 'Z' {
+				return r + 'a' - 'A'
+			}
+			return r
+		}, s)
+	})
+}
+
+func builtinASCIIUpcase(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return mapString(e, in, func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' {
+				return r - 'a' + 'A'
+			}
+			return r
+		}, s)
+	})
+}
+
+func mapString(e *env, in *jsonpart.Value, f func(s string) string) ([]*jsonpart.Value, error) {
+	s, err := inputString(in)
+	if err != nil {
+		return nil, err
+	}
+	return single(e.a.NewString(f(s)))
+}
+
+func builtinLtrimstr(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	prefix, err := evalString(e, in, args[0])
+	if err != nil || in.Type() != jsonpart.TypeString {
+		// jq returns non-string inputs as is.
+		return single(in)
+	}
+	return single(e.a.NewString(strings.TrimPrefix(stringOf(in), prefix)))
+}
+
+func builtinRtrimstr(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	suffix, err := evalString(e, in, args[0])
+	if err != nil || in.Type() != jsonpart.TypeString {
+		// jq returns non-string inputs as is.
+		return single(in)
+	}
+	return single(e.a.NewString(strings.TrimSuffix(stringOf(in), suffix)))
+}
+
+func builtinStartsWith(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return stringPredicate(e, in, args[0], strings.HasPrefix)
+}
+
+func builtinEndsWith(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return stringPredicate(e, in, args[0], strings.HasSuffix)
+}
+
+func builtinTest(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	s, err := inputString(in)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := evalString(e, in, args[0])
+	if err != nil {
+		return nil, err
+	}
+	re, err := e.regexp(expr)
+	if err != nil {
+		return nil, err
+	}
+	return single(e.newBool(re.MatchString(s)))
+}
+
+func stringPredicate(e *env, in *jsonpart.Value, arg node, f func(s, x string) bool) ([]*jsonpart.Value, error) {
+	s, err := inputString(in)
+	if err != nil {
+		return nil, err
+	}
+	x, err := evalString(e, in, arg)
+	if err != nil {
+		return nil, err
+	}
+	return single(e.newBool(f(s, x)))
+}
+
+func builtinSplit(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	s, err := inputString(in)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := evalString(e, in, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return single(e.split(s, sep))
+}
+
+func builtinJoin(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := evalString(e, in, args[0])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		switch item.Type() {
+		case jsonpart.TypeNull:
+		case jsonpart.TypeObject, jsonpart.TypeArray:
+			return nil, fmt.Errorf("cannot join %s", typeName(item))
+		default:
+			parts[i] = toString(item)
+		}
+	}
+	return single(e.a.NewString(strings.Join(parts, sep)))
+}
+
+func builtinContains(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	xs, err := args[0].eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, x := range xs {
+		ok, err := contains(in, x)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e.newBool(ok))
+	}
+	return out, nil
+}
+
+// contains implements jq contains: strings are matched by substring,
+// arrays and objects are matched recursively.
+func contains(a, b *jsonpart.Value) (bool, error) {
+	if typeOrder(a) != typeOrder(b) && !(isBool(a) && isBool(b)) {
+		return false, fmt.Errorf("%s and %s cannot have their containment checked", typeName(a), typeName(b))
+	}
+	switch a.Type() {
+	case jsonpart.TypeString:
+		return strings.Contains(stringOf(a), stringOf(b)), nil
+	case jsonpart.TypeArray:
+		for _, y := range b.GetArray() {
+			found := false
+			for _, x := range a.GetArray() {
+				if ok, _ := contains(x, y); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false, nil
+			}
+		}
+		return true, nil
+	case jsonpart.TypeObject:
+		ok := true
+		var err error
+		b.GetObject().Visit(func(k []byte, y *jsonpart.Value) {
+			if !ok || err != nil {
+				return
+			}
+			x := a.GetObject().Get(string(k))
+			if x == nil {
+				ok = false
+				return
+			}
+			ok, err = contains(x, y)
+		})
+		return ok, err
+	default:
+		return compare(a, b) == 0, nil
+	}
+}
+
+func isBool(v *jsonpart.Value) bool {
+	t := v.Type()
+	return t == jsonpart.TypeTrue || t == jsonpart.TypeFalse
+}
+
+func builtinToEntries(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() != jsonpart.TypeObject {
+		return nil, fmt.Errorf("cannot be applied to %s; it must be object", typeName(in))
+	}
+	a := e.a.NewArray()
+	in.GetObject().Visit(func(k []byte, v *jsonpart.Value) {
+		entry := e.a.NewObject()
+		entry.Set("key", e.a.NewString(string(k)))
+		entry.Set("value", v)
+		a.Append(entry)
+	})
+	return single(a)
+}
+
+func builtinFromEntries(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	items, err := inputArray(in)
+	if err != nil {
+		return nil, err
+	}
+	o := e.a.NewObject()
+	for _, item := range items {
+		if item.Type() != jsonpart.TypeObject {
+			return nil, fmt.Errorf("entries must be objects; got %s", typeName(item))
+		}
+		var k *jsonpart.Value
+		for _, name := range []string{"key", "k", "name", "Name", "Key", "K"} {
+			if k = item.Get(name); k != nil && isTruthy(k) {
+				break
+			}
+		}
+		if k == nil || !isTruthy(k) {
+			return nil, fmt.Errorf("entry %s has no key", item.MarshalString())
+		}
+		v := item.Get("value")
+		if v == nil {
+			v = item.Get("v")
+		}
+		if v == nil {
+			v = e.a.NewNull()
+		}
+		o.Set(toString(k), v)
+	}
+	return single(o)
+}
+
+func builtinWithEntries(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	entries, err := builtinToEntries(e, in, nil)
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := builtinMap(e, entries[0], args)
+	if err != nil {
+		return nil, err
+	}
+	return builtinFromEntries(e, mapped[0], nil)
+}
+
+func builtinValues(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	if in.Type() == jsonpart.TypeNull {
+		return nil, nil
+	}
+	return single(in)
+}
+
+func builtinRecurse(e *env, in *jsonpart.Value, args []node) ([]*jsonpart.Value, error) {
+	return (&recurseNode{}).eval(e, in)
+}
@@ -0,0 +1,825 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// env holds the state of a single Filter.Run call.
+type env struct {
+	// a allocates values constructed by the filter.
+	a jsonpart.Arena
+
+	// regexps caches regular expressions compiled by test,
+	// so they aren't recompiled for every input.
+	regexps map[string]*regexp.Regexp
+}
+
+// regexp returns compiled regular expression expr.
+func (e *env) regexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := e.regexps[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+	}
+	if e.regexps == nil {
+		e.regexps = make(map[string]*regexp.Regexp)
+	}
+	e.regexps[expr] = re
+	return re, nil
+}
+
+// node is a compiled filter expression.
+type node interface {
+	// eval returns all the outputs of the expression for the input in.
+	//
+	// On error it returns the outputs emitted before the error
+	// together with the error like jq does, so try may keep them.
+	eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error)
+}
+
+type identityNode struct{}
+
+func (n *identityNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{in}, nil
+}
+
+type recurseNode struct{}
+
+func (n *recurseNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	var out []*jsonpart.Value
+	var walk func(v *jsonpart.Value)
+	walk = func(v *jsonpart.Value) {
+		out = append(out, v)
+		switch v.Type() {
+		case jsonpart.TypeArray:
+			for _, item := range v.GetArray() {
+				walk(item)
+			}
+		case jsonpart.TypeObject:
+			v.GetObject().Visit(func(_ []byte, vv *jsonpart.Value) {
+				walk(vv)
+			})
+		}
+	}
+	walk(in)
+	return out, nil
+}
+
+type nullNode struct{}
+
+func (n *nullNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{e.a.NewNull()}, nil
+}
+
+type boolNode struct {
+	b bool
+}
+
+func (n *boolNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{e.newBool(n.b)}, nil
+}
+
+type numberNode struct {
+	s string
+}
+
+func (n *numberNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{e.a.NewNumberString(n.s)}, nil
+}
+
+type stringNode struct {
+	s string
+}
+
+func (n *stringNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return []*jsonpart.Value{e.a.NewString(n.s)}, nil
+}
+
+// interpNode is a string with \(expr) interpolations.
+type interpNode struct {
+	parts []node
+}
+
+func (n *interpNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	// Later interpolations vary slowest like in jq,
+	// so the parts are combined from the end.
+	suffixes := []string{""}
+	for i := len(n.parts) - 1; i >= 0; i-- {
+		vs, err := n.parts[i].eval(e, in)
+		if err != nil {
+			return nil, err
+		}
+		next := make([]string, 0, len(suffixes)*len(vs))
+		for _, suffix := range suffixes {
+			for _, v := range vs {
+				next = append(next, toString(v)+suffix)
+			}
+		}
+		suffixes = next
+	}
+	out := make([]*jsonpart.Value, len(suffixes))
+	for i, s := range suffixes {
+		out[i] = e.a.NewString(s)
+	}
+	return out, nil
+}
+
+type pipeNode struct {
+	l, r node
+}
+
+func (n *pipeNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	ls, lerr := n.l.eval(e, in)
+	var out []*jsonpart.Value
+	for _, l := range ls {
+		rs, err := n.r.eval(e, l)
+		out = append(out, rs...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, lerr
+}
+
+type commaNode struct {
+	l, r node
+}
+
+func (n *commaNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	ls, err := n.l.eval(e, in)
+	if err != nil {
+		return ls, err
+	}
+	rs, err := n.r.eval(e, in)
+	return append(ls, rs...), err
+}
+
+type altNode struct {
+	l, r node
+}
+
+func (n *altNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	// Errors in the left side are suppressed like in jq.
+	ls, _ := n.l.eval(e, in)
+	var out []*jsonpart.Value
+	for _, l := range ls {
+		if isTruthy(l) {
+			out = append(out, l)
+		}
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+	return n.r.eval(e, in)
+}
+
+type andNode struct {
+	l, r node
+}
+
+func (n *andNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return evalLogic(e, in, n.l, n.r, false)
+}
+
+type orNode struct {
+	l, r node
+}
+
+func (n *orNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	return evalLogic(e, in, n.l, n.r, true)
+}
+
+// evalLogic evaluates 'l or r' if isOr is set and 'l and r' otherwise.
+//
+// r isn't evaluated if l already determines the result.
+func evalLogic(e *env, in *jsonpart.Value, l, r node, isOr bool) ([]*jsonpart.Value, error) {
+	ls, lerr := l.eval(e, in)
+	var out []*jsonpart.Value
+	for _, lv := range ls {
+		if isTruthy(lv) == isOr {
+			out = append(out, e.newBool(isOr))
+			continue
+		}
+		rs, err := r.eval(e, in)
+		for _, rv := range rs {
+			out = append(out, e.newBool(isTruthy(rv)))
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, lerr
+}
+
+type ifNode struct {
+	cond node
+	then node
+
+	// els is nil if else branch is missing.
+	els node
+}
+
+func (n *ifNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	cs, cerr := n.cond.eval(e, in)
+	var out []*jsonpart.Value
+	for _, c := range cs {
+		var vs []*jsonpart.Value
+		var err error
+		switch {
+		case isTruthy(c):
+			vs, err = n.then.eval(e, in)
+		case n.els != nil:
+			vs, err = n.els.eval(e, in)
+		default:
+			vs = []*jsonpart.Value{in}
+		}
+		out = append(out, vs...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, cerr
+}
+
+type tryNode struct {
+	body node
+}
+
+func (n *tryNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	// Errors are suppressed, while the outputs emitted before the error are kept.
+	out, _ := n.body.eval(e, in)
+	return out, nil
+}
+
+type arrayNode struct {
+	// body is nil for empty array.
+	body node
+}
+
+func (n *arrayNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	a := e.a.NewArray()
+	if n.body != nil {
+		vs, err := n.body.eval(e, in)
+		if err != nil {
+			return nil, err
+		}
+		a.Append(vs...)
+	}
+	return []*jsonpart.Value{a}, nil
+}
+
+type objectEntry struct {
+	key   node
+	value node
+}
+
+type objectNode struct {
+	entries []objectEntry
+}
+
+func (n *objectNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	// Every entry may produce multiple keys and values,
+	// so the outputs are the cartesian product of them.
+	objs := []*jsonpart.Value{e.a.NewObject()}
+	for _, entry := range n.entries {
+		keys, err := entry.key.eval(e, in)
+		if err != nil {
+			return nil, err
+		}
+		values, err := entry.value.eval(e, in)
+		if err != nil {
+			return nil, err
+		}
+		next := make([]*jsonpart.Value, 0, len(objs)*len(keys)*len(values))
+		for _, obj := range objs {
+			for _, k := range keys {
+				if k.Type() != jsonpart.TypeString {
+					return nil, fmt.Errorf("object keys must be strings; got %s", typeName(k))
+				}
+				for _, v := range values {
+					o := obj
+					if len(keys)*len(values) > 1 {
+						o = e.copyObject(obj)
+					}
+					o.Set(stringOf(k), v)
+					next = append(next, o)
+				}
+			}
+		}
+		objs = next
+	}
+	return objs, nil
+}
+
+type negNode struct {
+	x node
+}
+
+func (n *negNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	xs, err := n.x.eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*jsonpart.Value, len(xs))
+	for i, x := range xs {
+		if x.Type() != jsonpart.TypeNumber {
+			return out[:i], fmt.Errorf("%s cannot be negated", typeName(x))
+		}
+		out[i] = e.newNumber(-floatOf(x))
+	}
+	return out, nil
+}
+
+type indexNode struct {
+	target node
+	index  node
+}
+
+func (n *indexNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	ts, terr := n.target.eval(e, in)
+	idxs, err := n.index.eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, t := range ts {
+		for _, idx := range idxs {
+			v, err := e.index(t, idx)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, terr
+}
+
+func (e *env) index(t, idx *jsonpart.Value) (*jsonpart.Value, error) {
+	switch {
+	case t.Type() == jsonpart.TypeObject && idx.Type() == jsonpart.TypeString:
+		if v := t.Get(stringOf(idx)); v != nil {
+			return v, nil
+		}
+		return e.a.NewNull(), nil
+	case t.Type() == jsonpart.TypeArray && idx.Type() == jsonpart.TypeNumber:
+		a := t.GetArray()
+		i := int(math.Floor(floatOf(idx)))
+		if i < 0 {
+			i += len(a)
+		}
+		if i < 0 || i >= len(a) {
+			return e.a.NewNull(), nil
+		}
+		return a[i], nil
+	case t.Type() == jsonpart.TypeNull && (idx.Type() == jsonpart.TypeString || idx.Type() == jsonpart.TypeNumber):
+		return e.a.NewNull(), nil
+	case idx.Type() == jsonpart.TypeString:
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(t), stringOf(idx))
+	default:
+		return nil, fmt.Errorf("cannot index %s with %s", typeName(t), typeName(idx))
+	}
+}
+
+type sliceNode struct {
+	target node
+
+	// from and to are nil if missing.
+	from node
+	to   node
+}
+
+func (n *sliceNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	ts, terr := n.target.eval(e, in)
+	bound := func(b node) ([]*jsonpart.Value, error) {
+		if b == nil {
+			return []*jsonpart.Value{nil}, nil
+		}
+		return b.eval(e, in)
+	}
+	froms, err := bound(n.from)
+	if err != nil {
+		return nil, err
+	}
+	tos, err := bound(n.to)
+	if err != nil {
+		return nil, err
+	}
+	var out []*jsonpart.Value
+	for _, t := range ts {
+		for _, from := range froms {
+			for _, to := range tos {
+				v, err := e.slice(t, from, to)
+				if err != nil {
+					return out, err
+				}
+				out = append(out, v)
+			}
+		}
+	}
+	return out, terr
+}
+
+func (e *env) slice(t, from, to *jsonpart.Value) (*jsonpart.Value, error) {
+	var length int
+	switch t.Type() {
+	case jsonpart.TypeNull:
+		return e.a.NewNull(), nil
+	case jsonpart.TypeArray:
+		length = len(t.GetArray())
+	case jsonpart.TypeString:
+		length = utf8.RuneCountInString(stringOf(t))
+	default:
+		return nil, fmt.Errorf("cannot slice %s", typeName(t))
+	}
+	bound := func(b *jsonpart.Value, def int) (int, error) {
+		if b == nil || b.Type() == jsonpart.TypeNull {
+			return def, nil
+		}
+		if b.Type() != jsonpart.TypeNumber {
+			return 0, fmt.Errorf("slice indexes must be numbers; got %s", typeName(b))
+		}
+		i := int(math.Floor(floatOf(b)))
+		if i < 0 {
+			i += length
+		}
+		if i < 0 {
+			i = 0
+		}
+		if i > length {
+			i = length
+		}
+		return i, nil
+	}
+	start, err := bound(from, 0)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(to, length)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		end = start
+	}
+	if t.Type() == jsonpart.TypeString {
+		rs := []rune(stringOf(t))
+		return e.a.NewString(string(rs[start:end])), nil
+	}
+	a := e.a.NewArray()
+	a.Append(t.GetArray()[start:end]...)
+	return a, nil
+}
+
+type iterateNode struct {
+	target node
+}
+
+func (n *iterateNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	ts, terr := n.target.eval(e, in)
+	var out []*jsonpart.Value
+	for _, t := range ts {
+		vs, err := iterate(t)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, vs...)
+	}
+	return out, terr
+}
+
+// iterate returns array items or object values of v.
+func iterate(v *jsonpart.Value) ([]*jsonpart.Value, error) {
+	switch v.Type() {
+	case jsonpart.TypeArray:
+		return v.GetArray(), nil
+	case jsonpart.TypeObject:
+		var vs []*jsonpart.Value
+		v.GetObject().Visit(func(_ []byte, vv *jsonpart.Value) {
+			vs = append(vs, vv)
+		})
+		return vs, nil
+	default:
+		return nil, fmt.Errorf("cannot iterate over %s", typeName(v))
+	}
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n *binaryNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	rs, err := n.r.eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	ls, err := n.l.eval(e, in)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*jsonpart.Value, 0, len(ls)*len(rs))
+	for _, r := range rs {
+		for _, l := range ls {
+			v, err := e.binary(n.op, l, r)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (e *env) binary(op string, l, r *jsonpart.Value) (*jsonpart.Value, error) {
+	switch op {
+	case "==":
+		return e.newBool(compare(l, r) == 0), nil
+	case "!=":
+		return e.newBool(compare(l, r) != 0), nil
+	case "<":
+		return e.newBool(compare(l, r) < 0), nil
+	case "<=":
+		return e.newBool(compare(l, r) <= 0), nil
+	case ">":
+		return e.newBool(compare(l, r) > 0), nil
+	case ">=":
+		return e.newBool(compare(l, r) >= 0), nil
+	}
+
+	lt, rt := l.Type(), r.Type()
+	if lt == jsonpart.TypeNumber && rt == jsonpart.TypeNumber {
+		x, y := floatOf(l), floatOf(r)
+		switch op {
+		case "+":
+			return e.newNumber(x + y), nil
+		case "-":
+			return e.newNumber(x - y), nil
+		case "*":
+			return e.newNumber(x * y), nil
+		case "/":
+			if y == 0 {
+				return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", l.MarshalString(), r.MarshalString())
+			}
+			return e.newNumber(x / y), nil
+		case "%":
+			xi, yi := int64(x), int64(y)
+			if yi == 0 {
+				return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", l.MarshalString(), r.MarshalString())
+			}
+			if yi < 0 {
+				yi = -yi
+			}
+			return e.newNumber(float64(xi % yi)), nil
+		}
+	}
+	switch op {
+	case "+":
+		switch {
+		case lt == jsonpart.TypeNull:
+			return r, nil
+		case rt == jsonpart.TypeNull:
+			return l, nil
+		case lt == jsonpart.TypeString && rt == jsonpart.TypeString:
+			return e.a.NewString(stringOf(l) + stringOf(r)), nil
+		case lt == jsonpart.TypeArray && rt == jsonpart.TypeArray:
+			a := e.a.NewArray()
+			a.Append(l.GetArray()...)
+			a.Append(r.GetArray()...)
+			return a, nil
+		case lt == jsonpart.TypeObject && rt == jsonpart.TypeObject:
+			o := e.copyObject(l)
+			r.GetObject().Visit(func(k []byte, v *jsonpart.Value) {
+				o.Set(string(k), v)
+			})
+			return o, nil
+		}
+	case "-":
+		if lt == jsonpart.TypeArray && rt == jsonpart.TypeArray {
+			a := e.a.NewArray()
+			for _, item := range l.GetArray() {
+				if !containsValue(r.GetArray(), item) {
+					a.Append(item)
+				}
+			}
+			return a, nil
+		}
+	case "*":
+		if lt == jsonpart.TypeObject && rt == jsonpart.TypeObject {
+			return e.mergeObjects(l, r), nil
+		}
+		if lt == jsonpart.TypeString && rt == jsonpart.TypeNumber {
+			n := floatOf(r)
+			if n <= 0 {
+				return e.a.NewNull(), nil
+			}
+			return e.a.NewString(strings.Repeat(stringOf(l), int(math.Ceil(n)))), nil
+		}
+	case "/":
+		if lt == jsonpart.TypeString && rt == jsonpart.TypeString {
+			return e.split(stringOf(l), stringOf(r)), nil
+		}
+	}
+	verbs := map[string]string{"+": "added", "-": "subtracted", "*": "multiplied", "/": "divided", "%": "divided"}
+	return nil, fmt.Errorf("%s and %s cannot be %s", typeName(l), typeName(r), verbs[op])
+}
+
+// mergeObjects returns object l recursively merged with object r like jq's * does.
+func (e *env) mergeObjects(l, r *jsonpart.Value) *jsonpart.Value {
+	o := e.copyObject(l)
+	r.GetObject().Visit(func(k []byte, v *jsonpart.Value) {
+		key := string(k)
+		if lv := o.Get(key); lv != nil && lv.Type() == jsonpart.TypeObject && v.Type() == jsonpart.TypeObject {
+			v = e.mergeObjects(lv, v)
+		}
+		o.Set(key, v)
+	})
+	return o
+}
+
+type callNode struct {
+	name string
+	fn   builtinFunc
+	args []node
+}
+
+func (n *callNode) eval(e *env, in *jsonpart.Value) ([]*jsonpart.Value, error) {
+	out, err := n.fn(e, in, n.args)
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return out, nil
+}
+
+func (e *env) newBool(b bool) *jsonpart.Value {
+	if b {
+		return e.a.NewTrue()
+	}
+	return e.a.NewFalse()
+}
+
+// newNumber returns number value for f.
+//
+// Integers are formatted without exponent like jq does.
+func (e *env) newNumber(f float64) *jsonpart.Value {
+	switch {
+	case math.IsNaN(f):
+		return e.a.NewNull()
+	case math.IsInf(f, 1):
+		f = math.MaxFloat64
+	case math.IsInf(f, -1):
+		f = -math.MaxFloat64
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e17 {
+		return e.a.NewNumberString(strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	return e.a.NewNumberString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+func (e *env) copyObject(v *jsonpart.Value) *jsonpart.Value {
+	o := e.a.NewObject()
+	v.GetObject().Visit(func(k []byte, vv *jsonpart.Value) {
+		o.Set(string(k), vv)
+	})
+	return o
+}
+
+func (e *env) split(s, sep string) *jsonpart.Value {
+	a := e.a.NewArray()
+	if s == "" {
+		return a
+	}
+	for _, part := range strings.Split(s, sep) {
+		a.Append(e.a.NewString(part))
+	}
+	return a
+}
+
+// isTruthy returns false only for false and null like jq does.
+func isTruthy(v *jsonpart.Value) bool {
+	t := v.Type()
+	return t != jsonpart.TypeFalse && t != jsonpart.TypeNull
+}
+
+// typeName returns jq type name of v.
+func typeName(v *jsonpart.Value) string {
+	switch t := v.Type(); t {
+	case jsonpart.TypeTrue, jsonpart.TypeFalse:
+		return "boolean"
+	default:
+		return t.String()
+	}
+}
+
+func stringOf(v *jsonpart.Value) string {
+	s, _ := v.String()
+	return s
+}
+
+func floatOf(v *jsonpart.Value) float64 {
+	f, _ := v.Float64()
+	return f
+}
+
+// toString returns strings as is and other values as JSON.
+func toString(v *jsonpart.Value) string {
+	if v.Type() == jsonpart.TypeString {
+		return stringOf(v)
+	}
+	return v.MarshalString()
+}
+
+// typeOrder returns the position of v type in jq sort order.
+func typeOrder(v *jsonpart.Value) int {
+	switch v.Type() {
+	case jsonpart.TypeNull:
+		return 0
+	case jsonpart.TypeFalse:
+		return 1
+	case jsonpart.TypeTrue:
+		return 2
+	case jsonpart.TypeNumber:
+		return 3
+	case jsonpart.TypeString:
+		return 4
+	case jsonpart.TypeArray:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// compare compares a and b according to jq ordering:
+// null < false < true < numbers < strings < arrays < objects.
+func compare(a, b *jsonpart.Value) int {
+	if ta, tb := typeOrder(a), typeOrder(b); ta != tb {
+		return ta - tb
+	}
+	switch a.Type() {
+	case jsonpart.TypeNumber:
+		x, y := floatOf(a), floatOf(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+	case jsonpart.TypeString:
+		return strings.Compare(stringOf(a), stringOf(b))
+	case jsonpart.TypeArray:
+		x, y := a.GetArray(), b.GetArray()
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(x) - len(y)
+	case jsonpart.TypeObject:
+		ka, kb := sortedKeys(a), sortedKeys(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+		}
+		if len(ka) != len(kb) {
+			return len(ka) - len(kb)
+		}
+		for _, k := range ka {
+			if c := compare(a.Get(k), b.Get(k)); c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func sortedKeys(v *jsonpart.Value) []string {
+	var keys []string
+	v.GetObject().Visit(func(k []byte, _ *jsonpart.Value) {
+		keys = append(keys, string(k))
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func containsValue(vs []*jsonpart.Value, v *jsonpart.Value) bool {
+	for _, x := range vs {
+		if compare(x, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Package filter implements jq-like filter language over jsonpart values.
+//
+// Supported syntax:
+//
+//	.                   identity
+//	..                  recursive descent
+//	.foo, ."foo"        object field; null input results in null
+//	.[expr]             object field or array item; negative indexes count from the end
+//	.[from:to]          array or string slice
+//	.[]                 iteration over array items or object values
+//	expr?               suppresses errors in expr
+//	a | b               pipe
+//	a, b                outputs of a followed by outputs of b
+//	a // b              outputs of a, which aren't false or null, or outputs of b
+//	[expr]              array construction
+//	{a: .x, "b": 1, (.k): .v, c}   object construction; c is a shorthand for c: .c
+//	"x=\(.x)"           string interpolation
+//	== != < <= > >=     comparisons with jq ordering of values
+//	+ - * / %           arithmetic, string, array and object operations
+//	and or not          boolean logic, where only false and null are falsy
+//	if c then a elif d then b else e end
+//	null true false, numbers and strings
+//
+// Builtin functions: select(f), map(f), map_values(f), keys, keys_unsorted,
+// has(k), length, type, empty, not, add, any, all, first, last, first(f),
+// last(f), limit(n; f), range(n), reverse, sort, sort_by(f), unique, min, max,
+// floor, ceil, tostring, tonumber, tojson, fromjson, ascii_downcase,
+// ascii_upcase, ltrimstr(s), rtrimstr(s), startswith(s), endswith(s),
+// split(s), join(s), test(re), contains(x), to_entries, from_entries,
+// with_entries(f), values, recurse.
+package filter
+
+import (
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// Filter is a compiled filter.
+//
+// Filter may be used from concurrent goroutines if the input values
+// are frozen or aren't shared between goroutines. See jsonpart.Value.Freeze.
+type Filter struct {
+	src  string
+	root node
+}
+
+// Compile compiles filter src.
+func Compile(src string) (*Filter, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{
+		src:  src,
+		root: root,
+	}, nil
+}
+
+// String returns the source of f.
+func (f *Filter) String() string {
+	return f.src
+}
+
+// Run runs f on v and returns all the outputs.
+//
+// nil v is treated as null. On error the outputs emitted before
+// the error are returned together with the error.
+//
+// The outputs may reference v, so they must not be modified
+// unless v may be modified. Values constructed by f are allocated
+// independently for every Run call.
+func (f *Filter) Run(v *jsonpart.Value) ([]*jsonpart.Value, error) {
+	e := &env{}
+	if v == nil {
+		v = e.a.NewNull()
+	}
+	return f.root.eval(e, v)
+}
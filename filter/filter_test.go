@@ -0,0 +1,253 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// run runs filter src on JSON input and returns space-separated outputs.
+func run(src, input string) (string, error) {
+	f, err := Compile(src)
+	if err != nil {
+		return "", err
+	}
+	v, err := jsonpart.Parse(input)
+	if err != nil {
+		return "", err
+	}
+	outs, err := f.Run(v)
+	a := make([]string, len(outs))
+	for i, out := range outs {
+		a[i] = out.MarshalString()
+	}
+	return strings.Join(a, " "), err
+}
+
+type runCase struct {
+	src    string
+	input  string
+	result string
+}
+
+func testRun(t *testing.T, cases []runCase) {
+	t.Helper()
+	for _, c := range cases {
+		result, err := run(c.src, c.input)
+		if err != nil {
+			t.Errorf("unexpected error for %s on %s: %s", c.src, c.input, err)
+			continue
+		}
+		if result != c.result {
+			t.Errorf("unexpected result for %s on %s; got %s; want %s", c.src, c.input, result, c.result)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, src := range []string{
+		"",
+		".[",
+		"1 +",
+		"(1",
+		"[1",
+		"{a",
+		`"\(1"`,
+		"foo",
+		"map",
+		"limit(1)",
+		".a |",
+	} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("expecting non-nil error when compiling %q", src)
+		}
+	}
+}
+
+func TestRunPrecedence(t *testing.T) {
+	testRun(t, []runCase{
+		{"1 + 2 * 3", "null", "7"},
+		{"(1 + 2) * 3", "null", "9"},
+		{"10 - 4 - 3", "null", "3"},
+		{"12 / 3 / 2", "null", "2"},
+		{"7 % 4 * 2", "null", "6"},
+		{"-1 + 2", "null", "1"},
+		{"1 + 2 == 3", "null", "true"},
+		{"1 < 2 and 2 < 1 or true", "null", "true"},
+		{"false or true and false", "null", "false"},
+		{"null // 1 + 1", "null", "2"},
+		{"1, 2 | . * 10", "null", "10 20"},
+		{"[.[] | . + 1]", "[1,2]", "[2,3]"},
+		{".a // .b, .c", `{"b":1,"c":2}`, "1 2"},
+		{"1, 2 // 3", "null", "1 2"},
+		{"[1, 2 | . + 1]", "null", "[2,3]"},
+	})
+}
+
+func TestRunPaths(t *testing.T) {
+	testRun(t, []runCase{
+		{".", `{"a":1}`, `{"a":1}`},
+		{".a", `{"a":1}`, "1"},
+		{".a.b", `{"a":{"b":[1]}}`, "[1]"},
+		{`."a b"`, `{"a b":1}`, "1"},
+		{".a", "null", "null"},
+		{".missing", `{"a":1}`, "null"},
+		{".[1]", "[1,2,3]", "2"},
+		{".[-1]", "[1,2,3]", "3"},
+		{".[5]", "[1,2,3]", "null"},
+		{`.["a"]`, `{"a":1}`, "1"},
+		{".[]", "[1,2]", "1 2"},
+		{".[]", `{"a":1,"b":2}`, "1 2"},
+		{".a[]", `{"a":[1,2]}`, "1 2"},
+		{"..", `[1,[2]]`, "[1,[2]] 1 [2] 2"},
+	})
+}
+
+func TestRunSlice(t *testing.T) {
+	testRun(t, []runCase{
+		{".[1:3]", "[0,1,2,3,4]", "[1,2]"},
+		{".[:2]", "[0,1,2,3,4]", "[0,1]"},
+		{".[3:]", "[0,1,2,3,4]", "[3,4]"},
+		{".[-2:]", "[0,1,2,3,4]", "[3,4]"},
+		{".[:-3]", "[0,1,2,3,4]", "[0,1]"},
+		{".[3:1]", "[0,1,2,3,4]", "[]"},
+		{".[10:20]", "[0,1,2]", "[]"},
+		{".[1:3]", `"abcd"`, `"bc"`},
+		{".[-2:]", `"abcd"`, `"cd"`},
+		{".[1:2]", "null", "null"},
+	})
+}
+
+func TestRunCartesian(t *testing.T) {
+	testRun(t, []runCase{
+		{`"\(1,2)-\(3,4)"`, "null", `"1-3" "2-3" "1-4" "2-4"`},
+		{"(1,2) + (10,20)", "null", "11 12 21 22"},
+		{"[(1,2) * (3,4)]", "null", "[3,6,4,8]"},
+		{"{a: (1,2)}", "null", `{"a":1} {"a":2}`},
+		{"{(\"a\",\"b\"): 1}", "null", `{"a":1} {"b":1}`},
+		{"{a: (1,2), b: (3,4)}", "null", `{"a":1,"b":3} {"a":1,"b":4} {"a":2,"b":3} {"a":2,"b":4}`},
+		{".[(0,1)]", "[5,6]", "5 6"},
+		{"if (true, false) then 1 else 2 end", "null", "1 2"},
+		{"[.[] | (1, 2)]", "[0,0]", "[1,2,1,2]"},
+		{"{a}", `{"a":1,"b":2}`, `{"a":1}`},
+	})
+}
+
+func TestRunOperators(t *testing.T) {
+	testRun(t, []runCase{
+		{`"a" + "b"`, "null", `"ab"`},
+		{"[1] + [2]", "null", "[1,2]"},
+		{`{"a":1} + {"b":2}`, "null", `{"a":1,"b":2}`},
+		{`{"a":{"b":1}} * {"a":{"c":2}}`, "null", `{"a":{"b":1,"c":2}}`},
+		{"null + 1", "null", "1"},
+		{"[1,2,1] - [1]", "null", "[2]"},
+		{`"a,b" / ","`, "null", `["a","b"]`},
+		{"[1, null] == [1, null]", "null", "true"},
+		{`null < false, false < 0, 0 < "a", "a" < [], [] < {}`, "null", "true true true true true"},
+		{`{"a":1} == {"a":1}`, "null", "true"},
+		{"1 != 1.0", "null", "false"},
+		{"if . then \"t\" elif . == false then \"f\" else \"n\" end", "false", `"f"`},
+		{"if . then 1 end", "null", "null"},
+	})
+}
+
+func TestRunErrorHandling(t *testing.T) {
+	testRun(t, []runCase{
+		{".a?", "1", ""},
+		{".[]?", "1", ""},
+		{"[.[] | .a?]", `[1,{"a":2}]`, "[2]"},
+		{"[.[] | (1, .a)?]", "[1]", "[1]"},
+		{".a // 5", `{"a":false}`, "5"},
+		{".a // 5", `{"a":0}`, "0"},
+		{"(1, null, 2) // 3", "null", "1 2"},
+		{"empty // 3", "null", "3"},
+		{"first(1, .a)", "1", "1"},
+		{"[limit(2; 1, 2, .a)]", "1", "[1,2]"},
+		{`[.[] | test("(")?]`, `["a"]`, "[]"},
+	})
+
+	for _, c := range []struct {
+		src     string
+		input   string
+		result  string
+		errText string
+	}{
+		{".a", "1", "", "cannot index number"},
+		{"1, .a, 2", "1", "1", "cannot index number"},
+		{".[] | .a", `[{"a":1},2]`, "1", "cannot index number"},
+		{`1 + "a"`, "null", "", "cannot be added"},
+		{`test("(")`, `"a"`, "", "invalid regular expression"},
+		{"-.", `"a"`, "", "cannot be negated"},
+	} {
+		result, err := run(c.src, c.input)
+		if err == nil {
+			t.Errorf("expecting non-nil error for %s on %s", c.src, c.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.errText) {
+			t.Errorf("unexpected error for %s on %s; got %q; want it containing %q", c.src, c.input, err, c.errText)
+		}
+		if result != c.result {
+			t.Errorf("unexpected outputs before error for %s on %s; got %s; want %s", c.src, c.input, result, c.result)
+		}
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	testRun(t, []runCase{
+		{"empty", "1", ""},
+		{"[1, empty, 2]", "null", "[1,2]"},
+		{"not", "false", "true"},
+		{"[.[] | select(. > 1)]", "[1,2,3]", "[2,3]"},
+		{"map(. * 2)", "[1,2]", "[2,4]"},
+		{"map_values(. + 1)", `{"a":1,"b":2}`, `{"a":2,"b":3}`},
+		{"keys", `{"b":1,"a":2}`, `["a","b"]`},
+		{"keys", "[5,6]", "[0,1]"},
+		{"keys_unsorted", `{"b":1,"a":2}`, `["b","a"]`},
+		{`has("a"), has("c")`, `{"a":1}`, "true false"},
+		{"has(1)", "[1,2]", "true"},
+		{"length", `"héllo"`, "5"},
+		{"length", "[1,2]", "2"},
+		{"length", `{"a":1}`, "1"},
+		{"length", "null", "0"},
+		{"length", "-3", "3"},
+		{"[.[] | type]", `[null,true,1,"a",[],{}]`, `["null","boolean","number","string","array","object"]`},
+		{"add", "[1,2,3]", "6"},
+		{"add", `["a","b"]`, `"ab"`},
+		{"add", "[]", "null"},
+		{"any, all", "[true,false]", "true false"},
+		{"any, all", "[]", "false true"},
+		{"first, last", "[1,2,3]", "1 3"},
+		{"first(range(5)), last(range(5))", "null", "0 4"},
+		{"[limit(2; .[])]", "[1,2,3]", "[1,2]"},
+		{"[range(3)]", "null", "[0,1,2]"},
+		{"reverse", "[1,2,3]", "[3,2,1]"},
+		{"sort", `[3,"a",null,1,true]`, `[null,true,1,3,"a"]`},
+		{"sort_by(.a)", `[{"a":2},{"a":1}]`, `[{"a":1},{"a":2}]`},
+		{"unique", "[2,1,2,1]", "[1,2]"},
+		{"min, max", "[3,1,2]", "1 3"},
+		{"min", "[]", "null"},
+		{"floor, ceil", "1.5", "1 2"},
+		{"tostring", "[1]", `"[1]"`},
+		{"tostring", `"a"`, `"a"`},
+		{"tonumber", `"12.5"`, "12.5"},
+		{"tojson", `{"a":[1]}`, `"{\"a\":[1]}"`},
+		{"fromjson", `"{\"a\":[1]}"`, `{"a":[1]}`},
+		{"ascii_downcase, ascii_upcase", `"aBc"`, `"abc" "ABC"`},
+		{`ltrimstr("a"), rtrimstr("c")`, `"abc"`, `"bc" "ab"`},
+		{`ltrimstr("a")`, "1", "1"},
+		{`startswith("ab"), endswith("bc")`, `"abc"`, "true true"},
+		{`split(",")`, `"a,b"`, `["a","b"]`},
+		{`join("-")`, `["a",1,null]`, `"a-1-"`},
+		{`test("^a.c$")`, `"abc"`, "true"},
+		{`contains("b")`, `"abc"`, "true"},
+		{`contains({"a":[1]})`, `{"a":[1,2],"b":3}`, "true"},
+		{"to_entries", `{"a":1}`, `[{"key":"a","value":1}]`},
+		{"from_entries", `[{"key":"a","value":1},{"name":"b","value":2}]`, `{"a":1,"b":2}`},
+		{"with_entries(select(.value > 1))", `{"a":1,"b":2}`, `{"b":2}`},
+		{`with_entries({key: (.key + "x"), value})`, `{"a":1}`, `{"ax":1}`},
+		{"[.[] | values]", "[1,null,2]", "[1,2]"},
+		{"[recurse]", "[[1]]", "[[[1]],[1],1]"},
+	})
+}
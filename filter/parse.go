@@ -0,0 +1,548 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// maxParseDepth limits nesting of filter expressions.
+const maxParseDepth = 300
+
+type parser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// syntaxError is used for aborting parsing via panic.
+type syntaxError struct {
+	err error
+}
+
+func parse(src string) (n node, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(syntaxError)
+			if !ok {
+				panic(r)
+			}
+			n = nil
+			err = se.err
+		}
+	}()
+	n = p.parsePipe()
+	p.skipWS()
+	if p.pos < len(p.src) {
+		p.fail("unexpected %q", p.rest())
+	}
+	return n, nil
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(syntaxError{
+		err: fmt.Errorf("cannot compile filter %q: %s at position %d", p.src, fmt.Sprintf(format, args...), p.pos),
+	})
+}
+
+// rest returns the start of the unparsed tail for error messages.
+func (p *parser) rest() string {
+	s := p.src[p.pos:]
+	if len(s) > 20 {
+		s = s[:20] + "..."
+	}
+	return s
+}
+
+func (p *parser) skipWS() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '#':
+			// Comment till the end of line.
+			n := strings.IndexByte(p.src[p.pos:], '\n')
+			if n < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += n + 1
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns true if the unparsed tail starts with s after whitespace.
+func (p *parser) peek(s string) bool {
+	p.skipWS()
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// consume consumes s if the unparsed tail starts with it.
+func (p *parser) consume(s string) bool {
+	if !p.peek(s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+func (p *parser) expect(s string) {
+	if !p.consume(s) {
+		if p.pos >= len(p.src) {
+			p.fail("missing %q", s)
+		}
+		p.fail("expecting %q instead of %q", s, p.rest())
+	}
+}
+
+// keyword consumes identifier kw.
+func (p *parser) keyword(kw string) bool {
+	if !p.peek(kw) {
+		return false
+	}
+	end := p.pos + len(kw)
+	if end < len(p.src) && isIdentChar(p.src[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) ident() string {
+	p.skipWS()
+	start := p.pos
+	if p.pos < len(p.src) && isIdentStart(p.src[p.pos]) {
+		p.pos++
+		for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	return p.src[start:p.pos]
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "and", "or", "if", "then", "elif", "else", "end":
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *parser) enter() {
+	p.depth++
+	if p.depth > maxParseDepth {
+		p.fail("too deep nesting; it exceeds %d", maxParseDepth)
+	}
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) parsePipe() node {
+	p.enter()
+	defer p.leave()
+	n := p.parseComma()
+	for p.consume("|") {
+		n = &pipeNode{l: n, r: p.parseComma()}
+	}
+	return n
+}
+
+func (p *parser) parseComma() node {
+	n := p.parseAlt()
+	for p.consume(",") {
+		n = &commaNode{l: n, r: p.parseAlt()}
+	}
+	return n
+}
+
+func (p *parser) parseAlt() node {
+	n := p.parseOr()
+	for p.consume("//") {
+		n = &altNode{l: n, r: p.parseOr()}
+	}
+	return n
+}
+
+func (p *parser) parseOr() node {
+	n := p.parseAnd()
+	for p.keyword("or") {
+		n = &orNode{l: n, r: p.parseAnd()}
+	}
+	return n
+}
+
+func (p *parser) parseAnd() node {
+	n := p.parseCompare()
+	for p.keyword("and") {
+		n = &andNode{l: n, r: p.parseCompare()}
+	}
+	return n
+}
+
+var compareOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *parser) parseCompare() node {
+	n := p.parseAdditive()
+	for _, op := range compareOps {
+		if p.consume(op) {
+			return &binaryNode{op: op, l: n, r: p.parseAdditive()}
+		}
+	}
+	return n
+}
+
+func (p *parser) parseAdditive() node {
+	n := p.parseMultiplicative()
+	for {
+		switch {
+		case p.consume("+"):
+			n = &binaryNode{op: "+", l: n, r: p.parseMultiplicative()}
+		case p.consume("-"):
+			n = &binaryNode{op: "-", l: n, r: p.parseMultiplicative()}
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) parseMultiplicative() node {
+	n := p.parsePostfix()
+	for {
+		switch {
+		case p.consume("*"):
+			n = &binaryNode{op: "*", l: n, r: p.parsePostfix()}
+		case p.peek("/") && !p.peek("//"):
+			p.pos++
+			n = &binaryNode{op: "/", l: n, r: p.parsePostfix()}
+		case p.consume("%"):
+			n = &binaryNode{op: "%", l: n, r: p.parsePostfix()}
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) parsePostfix() node {
+	n := p.parsePrimary()
+	for {
+		switch {
+		case p.peek(".") && !p.peek(".."):
+			// Field access after a term such as .a.b or .a."b" or .a.[0].
+			p.pos++
+			if p.pos < len(p.src) && p.src[p.pos] == '[' {
+				continue
+			}
+			n = &indexNode{target: n, index: p.parseFieldName()}
+		case p.consume("["):
+			n = p.parseBracketSuffix(n)
+		case p.consume("?"):
+			n = &tryNode{body: n}
+		default:
+			return n
+		}
+	}
+}
+
+// parseFieldName parses field name after '.'.
+func (p *parser) parseFieldName() node {
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		return p.parseString()
+	}
+	if p.pos >= len(p.src) || !isIdentStart(p.src[p.pos]) {
+		p.fail("missing field name after '.'")
+	}
+	return &stringNode{s: p.ident()}
+}
+
+// parseBracketSuffix parses the suffix after '[' applied to target.
+func (p *parser) parseBracketSuffix(target node) node {
+	if p.consume("]") {
+		return &iterateNode{target: target}
+	}
+	if p.consume(":") {
+		to := p.parsePipe()
+		p.expect("]")
+		return &sliceNode{target: target, to: to}
+	}
+	index := p.parsePipe()
+	if p.consume(":") {
+		var to node
+		if !p.peek("]") {
+			to = p.parsePipe()
+		}
+		p.expect("]")
+		return &sliceNode{target: target, from: index, to: to}
+	}
+	p.expect("]")
+	return &indexNode{target: target, index: index}
+}
+
+func (p *parser) parsePrimary() node {
+	p.skipWS()
+	if p.pos >= len(p.src) {
+		p.fail("unexpected end of filter")
+	}
+	c := p.src[p.pos]
+	switch {
+	case p.consume(".."):
+		return &recurseNode{}
+	case c == '.':
+		p.pos++
+		if p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || p.src[p.pos] == '"') {
+			return &indexNode{target: &identityNode{}, index: p.parseFieldName()}
+		}
+		return &identityNode{}
+	case c == '"':
+		return p.parseString()
+	case c >= '0' && c <= '9':
+		return p.parseNumber()
+	case c == '(':
+		p.pos++
+		n := p.parsePipe()
+		p.expect(")")
+		return n
+	case c == '[':
+		p.pos++
+		if p.consume("]") {
+			return &arrayNode{}
+		}
+		n := p.parsePipe()
+		p.expect("]")
+		return &arrayNode{body: n}
+	case c == '{':
+		p.pos++
+		return p.parseObject()
+	case c == '-':
+		p.pos++
+		return &negNode{x: p.parsePostfix()}
+	case c == '$':
+		p.fail("variables aren't supported")
+	case isIdentStart(c):
+		return p.parseIdent()
+	}
+	p.fail("unexpected %q", p.rest())
+	return nil
+}
+
+func (p *parser) parseIdent() node {
+	start := p.pos
+	name := p.ident()
+	switch name {
+	case "null":
+		return &nullNode{}
+	case "true":
+		return &boolNode{b: true}
+	case "false":
+		return &boolNode{b: false}
+	case "if":
+		return p.parseIf()
+	}
+	if isKeyword(name) {
+		p.pos = start
+		p.fail("unexpected keyword %q", name)
+	}
+	var args []node
+	if p.consume("(") {
+		args = append(args, p.parsePipe())
+		for p.consume(";") {
+			args = append(args, p.parsePipe())
+		}
+		p.expect(")")
+	}
+	fn := lookupBuiltin(name, len(args))
+	if fn == nil {
+		p.pos = start
+		p.fail("unknown function %s/%d", name, len(args))
+	}
+	return &callNode{name: name, fn: fn, args: args}
+}
+
+// parseIf parses the tail of if-then-elif-else-end expression.
+func (p *parser) parseIf() node {
+	n := &ifNode{cond: p.parsePipe()}
+	if !p.keyword("then") {
+		p.fail("missing 'then'")
+	}
+	n.then = p.parsePipe()
+	switch {
+	case p.keyword("elif"):
+		n.els = p.parseIf()
+		return n
+	case p.keyword("else"):
+		n.els = p.parsePipe()
+	}
+	if !p.keyword("end") {
+		p.fail("missing 'end'")
+	}
+	return n
+}
+
+func (p *parser) parseObject() node {
+	n := &objectNode{}
+	if p.consume("}") {
+		return n
+	}
+	for {
+		var e objectEntry
+		p.skipWS()
+		switch {
+		case p.pos < len(p.src) && p.src[p.pos] == '"':
+			e.key = p.parseString()
+		case p.consume("("):
+			e.key = p.parsePipe()
+			p.expect(")")
+			if !p.peek(":") {
+				p.fail("missing value for the computed key")
+			}
+		case p.pos < len(p.src) && isIdentStart(p.src[p.pos]):
+			e.key = &stringNode{s: p.ident()}
+		default:
+			p.fail("missing object key")
+		}
+		if p.consume(":") {
+			e.value = p.parseObjectValue()
+		} else {
+			// Shorthand {a} means {a: .a}.
+			e.value = &indexNode{target: &identityNode{}, index: e.key}
+		}
+		n.entries = append(n.entries, e)
+		if p.consume("}") {
+			return n
+		}
+		p.expect(",")
+	}
+}
+
+// parseObjectValue parses object value, which may contain pipes, but not commas.
+func (p *parser) parseObjectValue() node {
+	n := p.parseAlt()
+	for p.consume("|") {
+		n = &pipeNode{l: n, r: p.parseAlt()}
+	}
+	return n
+}
+
+func (p *parser) parseNumber() node {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if (c >= '0' && c <= '9') || c == '.' {
+			p.pos++
+			continue
+		}
+		if (c == 'e' || c == 'E') && p.pos+1 < len(p.src) {
+			p.pos++
+			if c := p.src[p.pos]; c == '+' || c == '-' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+	s := p.src[start:p.pos]
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		p.pos = start
+		p.fail("invalid number %q", s)
+	}
+	return &numberNode{s: s}
+}
+
+// parseString parses string literal with optional \(expr) interpolations.
+func (p *parser) parseString() node {
+	start := p.pos
+	p.pos++ // skip opening quote
+	var parts []node
+	var b []byte
+	for {
+		if p.pos >= len(p.src) {
+			p.pos = start
+			p.fail("missing closing '\"'")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			if len(parts) == 0 {
+				return &stringNode{s: string(b)}
+			}
+			if len(b) > 0 {
+				parts = append(parts, &stringNode{s: string(b)})
+			}
+			return &interpNode{parts: parts}
+		case '\\':
+			if p.pos >= len(p.src) {
+				continue
+			}
+			c = p.src[p.pos]
+			p.pos++
+			switch c {
+			case '"', '\\', '/':
+				b = append(b, c)
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'u':
+				b = utf8.AppendRune(b, p.parseUnicodeEscape())
+			case '(':
+				if len(b) > 0 {
+					parts = append(parts, &stringNode{s: string(b)})
+					b = b[:0]
+				}
+				parts = append(parts, p.parsePipe())
+				p.expect(")")
+			default:
+				p.pos -= 2
+				p.fail("invalid escape sequence %q", p.src[p.pos:p.pos+2])
+			}
+		default:
+			b = append(b, c)
+		}
+	}
+}
+
+// parseUnicodeEscape parses hex digits of \u escape sequence.
+func (p *parser) parseUnicodeEscape() rune {
+	hex := func() rune {
+		if p.pos+4 > len(p.src) {
+			p.fail("too short \\u escape sequence")
+		}
+		x, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 16)
+		if err != nil {
+			p.fail("invalid \\u escape sequence %q", p.src[p.pos:p.pos+4])
+		}
+		p.pos += 4
+		return rune(x)
+	}
+	r := hex()
+	if !utf16.IsSurrogate(r) {
+		return r
+	}
+	if !strings.HasPrefix(p.src[p.pos:], "\\u") {
+		return utf8.RuneError
+	}
+	p.pos += 2
+	return utf16.DecodeRune(r, hex())
+}
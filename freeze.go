@@ -0,0 +1,45 @@
+package jsonpart
+
+import (
+	"fmt"
+)
+
+// Freeze performs all the lazy work on v in advance and marks v read-only.
+//
+// Strings and object keys are unescaped on the first access by default,
+// so even read-only access to the same parsed value from concurrent goroutines
+// races. Frozen value may be read from concurrent goroutines via Get, GetXxx,
+// Visit and marshaling calls without synchronization.
+//
+// Modifying frozen value via Set, Del, SetArrayItem or Append panics.
+// Use Clone for obtaining a modifiable copy of frozen value.
+func (v *Value) Freeze() {
+	if v == nil {
+		return
+	}
+	switch v.vType() {
+	case TypeObject:
+		v.o.unescapeKeys()
+		v.o.frozen = true
+		for _, kv := range v.o.kvs {
+			kv.v.Freeze()
+		}
+	case TypeArray:
+		v.frozen = true
+		for _, item := range v.a {
+			item.Freeze()
+		}
+	}
+}
+
+func (o *Object) checkMutable() {
+	if o.frozen {
+		panic(fmt.Errorf("cannot modify frozen object; use Value.Clone for obtaining a modifiable copy"))
+	}
+}
+
+func (v *Value) checkMutable() {
+	if v.frozen {
+		panic(fmt.Errorf("cannot modify frozen array; use Value.Clone for obtaining a modifiable copy"))
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/jeffreyzj/jsonpart
+
+go 1.25.0
+
+require (
+	golang.org/x/text v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,217 @@
+package infer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goGenerator emits Go type declarations for shapes.
+type goGenerator struct {
+	buf bytes.Buffer
+
+	// names contains already used type names.
+	names map[string]bool
+
+	// pending contains nested struct declarations to emit.
+	pending []goDecl
+}
+
+type goDecl struct {
+	name  string
+	shape *Shape
+}
+
+// commonInitialisms are upper-cased in field names, like golint suggests.
+var commonInitialisms = map[string]bool{
+	"API":  true,
+	"CSS":  true,
+	"DNS":  true,
+	"HTML": true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"SQL":  true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+	"XML":  true,
+}
+
+func (g *goGenerator) declare(name string, s *Shape) {
+	g.pending = append(g.pending, goDecl{
+		name:  name,
+		shape: s,
+	})
+	for len(g.pending) > 0 {
+		d := g.pending[0]
+		g.pending = g.pending[1:]
+		fmt.Fprintf(&g.buf, "type %s %s\n\n", d.name, g.typeOf(d.name, d.shape, false))
+	}
+}
+
+// typeOf returns Go type for s.
+//
+// Nested object types are named with the given name. Struct declarations
+// are returned only for the top-level type, while nested structs are queued
+// into g.pending.
+func (g *goGenerator) typeOf(name string, s *Shape, nested bool) string {
+	k := s.kinds
+	// Declared types cannot be nullable, only references to them.
+	nullable := k&kindNull != 0 && nested
+	k &^= kindNull
+	if k&kindNumber != 0 {
+		k &^= kindInteger
+	}
+
+	var t string
+	switch k {
+	case 0:
+		return "any"
+	case kindBoolean:
+		t = "bool"
+	case kindInteger, kindNumber:
+		t = s.numberType(k)
+	case kindString:
+		t = "string"
+	case kindArray:
+		item := "any"
+		if s.items != nil {
+			item = g.typeOf(singular(name), s.items, true)
+		}
+		// nil slice already represents null.
+		return "[]" + item
+	case kindObject:
+		if nested {
+			t = g.uniqueName(name)
+			g.pending = append(g.pending, goDecl{
+				name:  t,
+				shape: s,
+			})
+		} else {
+			t = g.structOf(name, s)
+		}
+	default:
+		return "any"
+	}
+	if nullable {
+		t = "*" + t
+	}
+	return t
+}
+
+// numberType returns Go type for number samples of s with kind k.
+func (s *Shape) numberType(k kind) string {
+	switch {
+	case s.bigNumbers:
+		return "json.Number"
+	case k == kindNumber || s.floats:
+		return "float64"
+	default:
+		return "int64"
+	}
+}
+
+func (g *goGenerator) structOf(name string, s *Shape) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	fieldNames := make(map[string]bool)
+	for _, f := range s.fields {
+		fieldName := uniqueFieldName(fieldNames, goName(f.name))
+		t := g.typeOf(name+fieldName, f.shape, true)
+		tag := f.name
+		if f.count < s.objects {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s `json:%s`\n", fieldName, t, strconv.Quote(tag))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *goGenerator) uniqueName(name string) string {
+	n := name
+	for i := 2; g.names[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	g.names[n] = true
+	return n
+}
+
+func (g *goGenerator) format() ([]byte, error) {
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("BUG: cannot format generated code: %s; code:\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+func uniqueFieldName(used map[string]bool, name string) string {
+	n := name
+	for i := 2; used[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	used[n] = true
+	return n
+}
+
+// goName converts JSON key into exported Go identifier.
+func goName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		for _, part := range splitCamel(w) {
+			if u := strings.ToUpper(part); commonInitialisms[u] {
+				b.WriteString(u)
+				continue
+			}
+			rs := []rune(part)
+			rs[0] = unicode.ToUpper(rs[0])
+			b.WriteString(string(rs))
+		}
+	}
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if r := []rune(name)[0]; !unicode.IsUpper(r) {
+		// Digits and caseless letters cannot start exported identifiers.
+		name = "X" + name
+	}
+	return name
+}
+
+// splitCamel splits camelCase word into parts, so initialisms inside
+// the word such as userId may be recognized.
+func splitCamel(w string) []string {
+	var parts []string
+	rs := []rune(w)
+	start := 0
+	for i := 1; i < len(rs); i++ {
+		if unicode.IsUpper(rs[i]) && unicode.IsLower(rs[i-1]) {
+			parts = append(parts, string(rs[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(rs[start:]))
+}
+
+// singular returns the name for items of the array field with the given name.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
@@ -0,0 +1,240 @@
+// Package infer infers the shape of JSON data from sample jsonpart values.
+//
+// The inferred shape may be emitted as JSON Schema or as Go type declarations
+// with json tags. Shapes of all the samples are merged: object fields missing
+// in some samples become optional, distinct value types become unions
+// and array items of all the arrays are merged into a single item shape.
+package infer
+
+import (
+	"fmt"
+	"go/token"
+	"math"
+	"strings"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// kind is a bit set of JSON Schema types seen in samples.
+type kind uint8
+
+const (
+	kindNull kind = 1 << iota
+	kindBoolean
+	kindInteger
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// kindNames contains JSON Schema type names in the output order.
+var kindNames = []struct {
+	k    kind
+	name string
+}{
+	{kindObject, "object"},
+	{kindArray, "array"},
+	{kindString, "string"},
+	{kindNumber, "number"},
+	{kindInteger, "integer"},
+	{kindBoolean, "boolean"},
+	{kindNull, "null"},
+}
+
+// Shape is the merged shape of sample values.
+//
+// The zero Shape is ready for use.
+type Shape struct {
+	kinds kind
+
+	// objects is the number of object samples.
+	objects int
+
+	// fields contains object fields in the order of their first appearance.
+	fields     []*field
+	fieldIndex map[string]int
+
+	// items is the merged shape of array items.
+	items *Shape
+
+	// floats is set if some integer samples have fraction or exponent
+	// such as 1.0, so they cannot be decoded into int64.
+	floats bool
+
+	// bigNumbers is set if some number samples are out of int64 range
+	// for integer literals or out of float64 range for other numbers.
+	bigNumbers bool
+}
+
+type field struct {
+	name  string
+	count int
+	shape *Shape
+}
+
+// Infer returns the merged shape of the given samples.
+func Infer(samples ...*jsonpart.Value) *Shape {
+	s := &Shape{}
+	for _, v := range samples {
+		s.Add(v)
+	}
+	return s
+}
+
+// Add merges the shape of sample v into s.
+func (s *Shape) Add(v *jsonpart.Value) {
+	if v == nil {
+		return
+	}
+	switch v.Type() {
+	case jsonpart.TypeNull:
+		s.kinds |= kindNull
+	case jsonpart.TypeTrue, jsonpart.TypeFalse:
+		s.kinds |= kindBoolean
+	case jsonpart.TypeString:
+		s.kinds |= kindString
+	case jsonpart.TypeNumber:
+		s.addNumber(v)
+	case jsonpart.TypeArray:
+		s.kinds |= kindArray
+		if s.items == nil {
+			s.items = &Shape{}
+		}
+		for _, item := range v.GetArray() {
+			s.items.Add(item)
+		}
+	case jsonpart.TypeObject:
+		s.kinds |= kindObject
+		s.objects++
+		if s.fieldIndex == nil {
+			s.fieldIndex = make(map[string]int)
+		}
+		v.GetObject().Visit(func(key []byte, vv *jsonpart.Value) {
+			i, ok := s.fieldIndex[string(key)]
+			if !ok {
+				i = len(s.fields)
+				name := string(key)
+				s.fieldIndex[name] = i
+				s.fields = append(s.fields, &field{
+					name:  name,
+					shape: &Shape{},
+				})
+			}
+			f := s.fields[i]
+			f.count++
+			f.shape.Add(vv)
+		})
+	}
+}
+
+// addNumber merges the shape of number sample v into s.
+func (s *Shape) addNumber(v *jsonpart.Value) {
+	if _, err := v.Int64(); err == nil {
+		s.kinds |= kindInteger
+		return
+	}
+	if !strings.ContainsAny(string(v.Number()), ".eE") {
+		// Integer literal out of int64 range.
+		s.kinds |= kindInteger
+		s.bigNumbers = true
+		return
+	}
+	f, err := v.Float64()
+	switch {
+	case err != nil || math.IsInf(f, 0):
+		s.kinds |= kindNumber
+		s.bigNumbers = true
+	case f == math.Trunc(f):
+		s.kinds |= kindInteger
+		s.floats = true
+	default:
+		s.kinds |= kindNumber
+	}
+}
+
+// types returns JSON Schema type names for s.
+//
+// integer is merged into number if both are present.
+func (s *Shape) types() []string {
+	k := s.kinds
+	if k&kindNumber != 0 {
+		k &^= kindInteger
+	}
+	var names []string
+	for _, kn := range kindNames {
+		if k&kn.k != 0 {
+			names = append(names, kn.name)
+		}
+	}
+	return names
+}
+
+// JSONSchema returns JSON Schema (draft 2020-12) describing s.
+func (s *Shape) JSONSchema() *jsonpart.Value {
+	var a jsonpart.Arena
+	v := a.NewObject()
+	v.Set("$schema", a.NewString("https://json-schema.org/draft/2020-12/schema"))
+	return s.jsonSchema(&a, v)
+}
+
+// jsonSchema adds JSON Schema keywords for s to v and returns v.
+func (s *Shape) jsonSchema(a *jsonpart.Arena, v *jsonpart.Value) *jsonpart.Value {
+	types := s.types()
+	switch len(types) {
+	case 0:
+		// No samples - any value is allowed.
+		return v
+	case 1:
+		v.Set("type", a.NewString(types[0]))
+	default:
+		ta := a.NewArray()
+		for _, t := range types {
+			ta.Append(a.NewString(t))
+		}
+		v.Set("type", ta)
+	}
+	if s.kinds&kindObject != 0 {
+		props := a.NewObject()
+		required := a.NewArray()
+		for _, f := range s.fields {
+			props.Set(f.name, f.shape.jsonSchema(a, a.NewObject()))
+			if f.count == s.objects {
+				required.Append(a.NewString(f.name))
+			}
+		}
+		v.Set("properties", props)
+		if len(required.GetArray()) > 0 {
+			v.Set("required", required)
+		}
+	}
+	if s.kinds&kindArray != 0 && s.items != nil && s.items.kinds != 0 {
+		v.Set("items", s.items.jsonSchema(a, a.NewObject()))
+	}
+	return v
+}
+
+// String returns JSON Schema for s as indented JSON.
+func (s *Shape) String() string {
+	return string(s.JSONSchema().MarshalIndent("", "  "))
+}
+
+// GoStruct returns Go type declarations for s with the root type called typeName.
+//
+// Nested objects are declared as separate types prefixed with typeName.
+// Fields missing in some samples get omitempty option, values seen as null
+// become pointers, and unions of incompatible types become any.
+// Integers are declared as int64 only if all the samples are integer literals
+// in int64 range. Numbers out of int64 and float64 range are declared
+// as json.Number, so the generated code must import encoding/json then.
+func (s *Shape) GoStruct(typeName string) ([]byte, error) {
+	if !token.IsIdentifier(typeName) {
+		return nil, fmt.Errorf("invalid Go type name %q", typeName)
+	}
+	g := &goGenerator{
+		names: make(map[string]bool),
+	}
+	g.names[typeName] = true
+	g.declare(typeName, s)
+	return g.format()
+}
@@ -0,0 +1,296 @@
+package jsonpart
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// NumberMode controls how JSON numbers are converted by Value.InterfaceWith.
+type NumberMode int
+
+const (
+	// NumberFloat64 converts numbers to float64.
+	NumberFloat64 NumberMode = 0
+
+	// NumberJSON converts numbers to json.Number holding the original text.
+	NumberJSON NumberMode = 1
+
+	// NumberInt64 converts integer numbers to int64.
+	//
+	// Numbers that don't fit int64 are converted to float64.
+	NumberInt64 NumberMode = 2
+
+	// NumberBigFloat converts numbers to *big.Float.
+	NumberBigFloat NumberMode = 3
+)
+
+// InterfaceOptions contains options for Value.InterfaceWith.
+type InterfaceOptions struct {
+	// Number is the conversion mode for JSON numbers.
+	Number NumberMode
+
+	// Ordered converts JSON objects to OrderedMap instead of map[string]any,
+	// so the original key order is preserved.
+	Ordered bool
+}
+
+// MapItem is a single key-value pair of OrderedMap.
+type MapItem struct {
+	Key   string
+	Value any
+}
+
+// OrderedMap is a JSON object with the preserved key order.
+type OrderedMap []MapItem
+
+// Get returns the value for the given key in the m.
+func (m OrderedMap) Get(key string) (any, bool) {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// Keys are marshaled in the order they are stored in m.
+func (m OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(item.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal value for key %q: %w", item.Key, err)
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Interface converts v to native Go value.
+//
+// Objects are converted to map[string]any, arrays to []any, numbers to float64,
+// strings to string, booleans to bool and null to nil.
+//
+// See InterfaceWith for more conversion options.
+func (v *Value) Interface() any {
+	return v.InterfaceWith(InterfaceOptions{})
+}
+
+// InterfaceWith converts v to native Go value according to opts.
+//
+// The returned value doesn't reference v, so it remains valid after v is modified.
+func (v *Value) InterfaceWith(opts InterfaceOptions) any {
+	if v == nil {
+		return nil
+	}
+	switch v.vType() {
+	case TypeObject:
+		v.o.unescapeKeys()
+		if opts.Ordered {
+			m := make(OrderedMap, 0, len(v.o.kvs))
+			for _, kv := range v.o.kvs {
+				m = append(m, MapItem{
+					Key:   copyString(kv.k),
+					Value: kv.v.InterfaceWith(opts),
+				})
+			}
+			return m
+		}
+		m := make(map[string]any, len(v.o.kvs))
+		for _, kv := range v.o.kvs {
+			m[copyString(kv.k)] = kv.v.InterfaceWith(opts)
+		}
+		return m
+	case TypeArray:
+		a := make([]any, len(v.a))
+		for i, vv := range v.a {
+			a[i] = vv.InterfaceWith(opts)
+		}
+		return a
+	case TypeString:
+		return copyString(v.s)
+	case TypeNumber:
+		return numberInterface(v.s, opts.Number)
+	case TypeTrue:
+		return true
+	case TypeFalse:
+		return false
+	case TypeNull:
+		return nil
+	default:
+		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
+	}
+}
+
+func numberInterface(s string, mode NumberMode) any {
+	switch mode {
+	case NumberJSON:
+		return json.Number(copyString(s))
+	case NumberInt64:
+		if n, err := parseInt64(s); err == nil {
+			return n
+		}
+		return parseBestEffort(s)
+	case NumberBigFloat:
+		f, err := parseBigFloat(s)
+		if err != nil {
+			// NaN and Inf cannot be represented by big.Float.
+			return parseBestEffort(s)
+		}
+		return f
+	default:
+		return parseBestEffort(s)
+	}
+}
+
+// FromInterface builds Value from native Go value x.
+//
+// x may contain nil, bool, string, integer and floating-point types, json.Number,
+// *big.Int, *big.Float, map[string]any, []any, OrderedMap and *Value.
+// Other types are converted via encoding/json. map[string]any keys are sorted.
+//
+// The returned value doesn't reference x and isn't shared with other values.
+func FromInterface(x any) (*Value, error) {
+	switch t := x.(type) {
+	case nil:
+		return &Value{t: TypeNull}, nil
+	case *Value:
+		if t == nil {
+			return &Value{t: TypeNull}, nil
+		}
+		return t.Clone(), nil
+	case bool:
+		if t {
+			return &Value{t: TypeTrue}, nil
+		}
+		return &Value{t: TypeFalse}, nil
+	case string:
+		return &Value{t: TypeString, s: copyString(t)}, nil
+	case json.Number:
+		s := string(t)
+		if _, err := parse(s); err != nil {
+			return nil, fmt.Errorf("invalid json.Number %q: %s", s, err)
+		}
+		return &Value{t: TypeNumber, s: copyString(s)}, nil
+	case float64:
+		return newFloatValue(t)
+	case float32:
+		return newFloatValue(float64(t))
+	case int:
+		return &Value{t: TypeNumber, s: strconv.FormatInt(int64(t), 10)}, nil
+	case int8:
+		return &Value{t: TypeNumber, s: strconv.FormatInt(int64(t), 10)}, nil
+	case int16:
+		return &Value{t: TypeNumber, s: strconv.FormatInt(int64(t), 10)}, nil
+	case int32:
+		return &Value{t: TypeNumber, s: strconv.FormatInt(int64(t), 10)}, nil
+	case int64:
+		return &Value{t: TypeNumber, s: strconv.FormatInt(t, 10)}, nil
+	case uint:
+		return &Value{t: TypeNumber, s: strconv.FormatUint(uint64(t), 10)}, nil
+	case uint8:
+		return &Value{t: TypeNumber, s: strconv.FormatUint(uint64(t), 10)}, nil
+	case uint16:
+		return &Value{t: TypeNumber, s: strconv.FormatUint(uint64(t), 10)}, nil
+	case uint32:
+		return &Value{t: TypeNumber, s: strconv.FormatUint(uint64(t), 10)}, nil
+	case uint64:
+		return &Value{t: TypeNumber, s: strconv.FormatUint(t, 10)}, nil
+	case *big.Int:
+		if t == nil {
+			return &Value{t: TypeNull}, nil
+		}
+		return &Value{t: TypeNumber, s: t.String()}, nil
+	case *big.Float:
+		if t == nil {
+			return &Value{t: TypeNull}, nil
+		}
+		if t.IsInf() {
+			return nil, fmt.Errorf("cannot convert %s to JSON number", t.String())
+		}
+		return &Value{t: TypeNumber, s: t.Text('g', -1)}, nil
+	case map[string]any:
+		// Sort keys like encoding/json does, so the result is deterministic.
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		v := &Value{t: TypeObject}
+		v.o.keysUnescaped = true
+		v.o.kvs = make([]kv, 0, len(t))
+		for _, k := range keys {
+			xv := t[k]
+			vv, err := FromInterface(xv)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert value for key %q: %w", k, err)
+			}
+			v.o.kvs = append(v.o.kvs, kv{k: copyString(k), v: vv})
+		}
+		return v, nil
+	case OrderedMap:
+		v := &Value{t: TypeObject}
+		v.o.keysUnescaped = true
+		v.o.kvs = make([]kv, 0, len(t))
+		for _, item := range t {
+			vv, err := FromInterface(item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert value for key %q: %w", item.Key, err)
+			}
+			v.o.kvs = append(v.o.kvs, kv{k: copyString(item.Key), v: vv})
+		}
+		return v, nil
+	case []any:
+		v := &Value{t: TypeArray}
+		v.a = make([]*Value, 0, len(t))
+		for i, xv := range t {
+			vv, err := FromInterface(xv)
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert array item #%d: %w", i, err)
+			}
+			v.a = append(v.a, vv)
+		}
+		return v, nil
+	}
+
+	if rv := reflect.ValueOf(x); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return &Value{t: TypeNull}, nil
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %T to Value: %w", x, err)
+	}
+	p := &parser{}
+	return p.parse(b2s(b), 0)
+}
+
+func newFloatValue(f float64) (*Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("cannot convert %v to JSON number", f)
+	}
+	return &Value{t: TypeNumber, s: strconv.FormatFloat(f, 'g', -1, 64)}, nil
+}
+
+// copyString returns a copy of s, which doesn't reference the memory of s.
+func copyString(s string) string {
+	return string(append([]byte(nil), s...))
+}
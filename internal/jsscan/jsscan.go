@@ -0,0 +1,246 @@
+// Package jsscan scans HTML pages for <script> bodies
+// and JavaScript assignments.
+package jsscan
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// scriptRe matches <script> tags with their attributes and bodies.
+	scriptRe = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script\s*>`)
+
+	// scriptIDRe matches id attribute inside tag attributes.
+	scriptIDRe = regexp.MustCompile(`(?is)(?:^|\s)id\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// ScriptBody returns the body of the first <script> tag with the given id in s.
+//
+// Whitespace and HTML comment markers around the body are trimmed, since
+// some pages wrap script bodies into comments for ancient browsers.
+// false is returned if s has no <script> tag with the given id.
+func ScriptBody(s, id string) (string, bool) {
+	var body string
+	found := false
+	VisitScripts(s, func(scriptID string, start, end int) bool {
+		if scriptID != id {
+			return true
+		}
+		body = s[start:end]
+		found = true
+		return false
+	})
+	return body, found
+}
+
+// VisitScripts calls f for every <script> tag in s with the tag id
+// and the offsets of the trimmed tag body.
+//
+// It stops when f returns false. The id is empty if the tag has no id.
+func VisitScripts(s string, f func(id string, start, end int) bool) {
+	for _, m := range scriptRe.FindAllStringSubmatchIndex(s, -1) {
+		var id string
+		if am := scriptIDRe.FindStringSubmatch(s[m[2]:m[3]]); am != nil {
+			id = am[1] + am[2] + am[3]
+		}
+		start := trimSpaceLeft(s, m[4], m[5])
+		end := trimSpaceRight(s, start, m[5])
+		if strings.HasPrefix(s[start:end], "<!--") {
+			start = trimSpaceLeft(s, start+len("<!--"), end)
+		}
+		if strings.HasSuffix(s[start:end], "-->") {
+			end = trimSpaceRight(s, start, end-len("-->"))
+		}
+		if !f(id, start, end) {
+			return
+		}
+	}
+}
+
+// CanonicalName returns the canonical form of JavaScript variable name
+// for comparing names.
+//
+// Bracketed string properties are converted to dotted ones and the global
+// object is stripped, so window["x"], window.x and x have the same form.
+func CanonicalName(name string) string {
+	if strings.IndexByte(name, '[') >= 0 {
+		var b strings.Builder
+		for {
+			n := strings.IndexByte(name, '[')
+			if n < 0 {
+				b.WriteString(name)
+				break
+			}
+			b.WriteString(name[:n])
+			name = name[n:]
+			prop, size := bracketProperty(name)
+			if size == 0 {
+				b.WriteByte('[')
+				name = name[1:]
+				continue
+			}
+			b.WriteByte('.')
+			b.WriteString(prop)
+			name = name[size:]
+		}
+		name = b.String()
+	}
+	for _, prefix := range []string{"window.", "self.", "globalThis."} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name
+}
+
+// bracketProperty returns the property from ["prop"] or ['prop'] at the start of s
+// and the size of the bracketed property. Zero size is returned if s doesn't
+// start with bracketed string property.
+func bracketProperty(s string) (string, int) {
+	if len(s) < 4 || s[0] != '[' || (s[1] != '"' && s[1] != '\'') {
+		return "", 0
+	}
+	n := strings.IndexByte(s[2:], s[1])
+	if n < 0 || 2+n+1 >= len(s) || s[2+n+1] != ']' {
+		return "", 0
+	}
+	return s[2 : 2+n], 2 + n + 2
+}
+
+// compactName removes whitespace outside quoted properties from name.
+func compactName(name string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case isSpace(c):
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// VisitAssignments calls f for every JavaScript assignment in s with
+// the assigned name and the offset of the assigned value.
+//
+// It stops when f returns false. Names may contain properties such as
+// window.__STATE__ and window["__STATE__"]. Whitespace outside quoted
+// properties is removed from names. Use CanonicalName for comparing names.
+func VisitAssignments(s string, f func(name string, start int) bool) {
+	for i := 0; i < len(s); i++ {
+		n := strings.IndexByte(s[i:], '=')
+		if n < 0 {
+			return
+		}
+		i += n
+		if i+1 < len(s) && (s[i+1] == '=' || s[i+1] == '>') {
+			// Comparison such as x == y or arrow function.
+			i++
+			continue
+		}
+		if i > 0 && strings.IndexByte("=!<>+-*/%&|^?", s[i-1]) >= 0 {
+			// Comparison or compound assignment such as x += y.
+			continue
+		}
+		nameStart := assignedNameStart(s, i)
+		if nameStart < 0 {
+			continue
+		}
+		name := compactName(s[nameStart:i])
+		start := trimSpaceLeft(s, i+1, len(s))
+		if !f(name, start) {
+			return
+		}
+	}
+}
+
+// assignedNameStart returns the start of the name assigned by '=' at s[end].
+//
+// -1 is returned if there is no name before end or if the name is
+// a property of an expression such as f().x.
+func assignedNameStart(s string, end int) int {
+	i := trimSpaceRight(s, 0, end)
+	for {
+		if i > 0 && s[i-1] == ']' {
+			// Bracketed property such as ["__STATE__"].
+			i = bracketStart(s, i)
+			if i < 0 {
+				return -1
+			}
+			continue
+		}
+		j := i
+		for j > 0 && isJSIdentByte(s[j-1]) {
+			j--
+		}
+		for j < i && s[j] >= '0' && s[j] <= '9' {
+			// Identifiers cannot start with digits.
+			j++
+		}
+		if j == i {
+			return -1
+		}
+		i = j
+		k := trimSpaceRight(s, 0, i)
+		if k == 0 || s[k-1] != '.' {
+			return i
+		}
+		i = trimSpaceRight(s, 0, k-1)
+	}
+}
+
+// bracketStart returns the offset of '[' for the bracketed string property
+// ending at s[end-1], or -1 if there is no such property.
+func bracketStart(s string, end int) int {
+	i := trimSpaceRight(s, 0, end-1)
+	if i == 0 || (s[i-1] != '"' && s[i-1] != '\'') {
+		return -1
+	}
+	quote := s[i-1]
+	i--
+	for {
+		if i == 0 || s[i-1] == '\n' {
+			return -1
+		}
+		i--
+		if s[i] == quote {
+			break
+		}
+	}
+	i = trimSpaceRight(s, 0, i)
+	if i == 0 || s[i-1] != '[' {
+		return -1
+	}
+	return i - 1
+}
+
+func isJSIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '$'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// trimSpaceLeft returns the offset of the first non-space byte in s[start:end].
+func trimSpaceLeft(s string, start, end int) int {
+	for start < end && isSpace(s[start]) {
+		start++
+	}
+	return start
+}
+
+// trimSpaceRight returns the offset after the last non-space byte in s[start:end].
+func trimSpaceRight(s string, start, end int) int {
+	for end > start && isSpace(s[end-1]) {
+		end--
+	}
+	return end
+}
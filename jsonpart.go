@@ -3,40 +3,101 @@ package jsonpart
 // fork from fastjson
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"strconv"
 	"strings"
 	"unicode/utf16"
+	"unicode/utf8"
 	"unsafe"
 )
 
+// ErrPartialKeyNotFound is returned by Parse when partialKey cannot be found in s.
+//
+// Use errors.Is for checking the returned error.
+var ErrPartialKeyNotFound = errors.New("cannot find partialKey")
+
 // Parse s contain json string embedded in, get partial value by specified key
 // full s json will be parse if partialKey is empty or ""
 func Parse(s string, partialKey ...string) (*Value, error) {
-	// find partialKey
-	if len(partialKey) > 0 && len(partialKey[0]) > 0 {
-		i := strings.Index(s, fmt.Sprintf("\"%s\"", partialKey[0]))
-		if i == -1 {
-			return nil, fmt.Errorf("cannot find partialKey: \"%s\"; JSON: %q", partialKey[0], startEndString(s))
-		}
-		s = s[i:]
-		v := s[len(partialKey[0])+2:]
-		v = skipWS(v)
-		if v[0] != ':' {
-			return nil, fmt.Errorf("invalid partialKey: \"%s\"; JSON: %q", partialKey[0], startEndString(s))
-		}
-		s = v[1:]
+	tail, err := locatePartial(s, partialKey...)
+	if err != nil {
+		return nil, err
 	}
 	p := &parser{}
-	return p.parse(s)
+	return p.parse(tail, len(s)-len(tail))
 }
 
 func ParseBytes(b []byte, partialKey ...string) (*Value, error) {
 	return Parse(b2s(b), partialKey...)
 }
 
+// ParseOptions contains options for ParseWithOptions.
+type ParseOptions struct {
+	// Detach makes the returned value self-contained.
+	//
+	// By default the returned value references the parser buffer holding
+	// the whole input after partialKey. Detached value is cloned into
+	// exactly sized independent memory, so it doesn't keep the input alive
+	// and may be safely cached or kept after the parser is released.
+	//
+	// Detached values have no source spans. See Value.Raw.
+	Detach bool
+
+	// Freeze freezes the returned value. See Value.Freeze.
+	Freeze bool
+
+	// UTF8 is the policy for invalid UTF-8 bytes and lone surrogate escapes
+	// in strings and object keys. See UTF8Policy.
+	//
+	// Strings and keys are unescaped eagerly if the policy isn't UTF8Keep,
+	// so the policy errors are returned by ParseWithOptions.
+	UTF8 UTF8Policy
+}
+
+// ParseWithOptions is like Parse, but applies the given opts to the returned value.
+func ParseWithOptions(s string, opts ParseOptions, partialKey ...string) (*Value, error) {
+	v, err := Parse(s, partialKey...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.UTF8 != UTF8Keep {
+		if err := v.applyUTF8Policy(opts.UTF8, nil); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Detach {
+		v = v.Clone()
+	}
+	if opts.Freeze {
+		v.Freeze()
+	}
+	return v, nil
+}
+
+// locatePartial returns the tail of s starting at the value for partialKey.
+//
+// s is returned unchanged if partialKey is empty.
+func locatePartial(s string, partialKey ...string) (string, error) {
+	if len(partialKey) == 0 || len(partialKey[0]) == 0 {
+		return s, nil
+	}
+	i := strings.Index(s, fmt.Sprintf("\"%s\"", partialKey[0]))
+	if i == -1 {
+		return "", fmt.Errorf("%w: \"%s\"; JSON: %q", ErrPartialKeyNotFound, partialKey[0], startEndString(s))
+	}
+	s = s[i:]
+	v := s[len(partialKey[0])+2:]
+	v = skipWS(v)
+	if len(v) == 0 || v[0] != ':' {
+		return "", fmt.Errorf("invalid partialKey: \"%s\"; JSON: %q", partialKey[0], startEndString(s))
+	}
+	return v[1:], nil
+}
+
 // parser parses JSON.
 //
 // parser may be re-used for subsequent parsing.
@@ -51,10 +112,15 @@ type parser struct {
 	c cache
 }
 
-func (p *parser) parse(s string) (*Value, error) {
-	s = skipWS(s)
+// parse parses s, which starts at the offset base in the whole input.
+func (p *parser) parse(s string, base int) (*Value, error) {
+	tail := skipWS(s)
+	base += len(s) - len(tail)
+	s = tail
 	p.b = append(p.b[:0], s...)
 	p.c.reset()
+	p.c.base = base
+	p.c.n = len(p.b)
 
 	v, tail, err := parseValue(b2s(p.b), &p.c, 0)
 	if err != nil {
@@ -65,6 +131,13 @@ func (p *parser) parse(s string) (*Value, error) {
 
 type cache struct {
 	vs []Value
+
+	// base is the offset of the parsed string in the whole input.
+	base int
+
+	// n is the length of the parsed string.
+	// It is used for calculating value offsets from unparsed tails.
+	n int
 }
 
 func (c *cache) reset() {
@@ -78,53 +151,58 @@ func (c *cache) getValue() *Value {
 		c.vs = append(c.vs, Value{})
 	}
 	// Do not reset the value, since the caller must properly init it.
-	return &c.vs[len(c.vs)-1]
+	// The source span and loneSurrogates are cleared though, since they are
+	// set only by the parser and by the lazy unescaping.
+	v := &c.vs[len(c.vs)-1]
+	v.raw = ""
+	v.loneSurrogates = false
+	return v
 }
 
-// vType represents JSON type.
-type vType int
+// Type represents JSON type.
+type Type int
 
 const (
-	// typeNull is JSON null.
-	typeNull vType = 0
+	// TypeNull is JSON null.
+	TypeNull Type = 0
 
-	// typeObject is JSON object type.
-	typeObject vType = 1
+	// TypeObject is JSON object type.
+	TypeObject Type = 1
 
-	// typeArray is JSON array type.
-	typeArray vType = 2
+	// TypeArray is JSON array type.
+	TypeArray Type = 2
 
-	// typeString is JSON string type.
-	typeString vType = 3
+	// TypeString is JSON string type.
+	TypeString Type = 3
 
-	// typeNumber is JSON number type.
-	typeNumber vType = 4
+	// TypeNumber is JSON number type.
+	TypeNumber Type = 4
 
-	// typeTrue is JSON true.
-	typeTrue vType = 5
+	// TypeTrue is JSON true.
+	TypeTrue Type = 5
 
-	// typeFalse is JSON false.
-	typeFalse vType = 6
+	// TypeFalse is JSON false.
+	TypeFalse Type = 6
 
-	typeRawString vType = 7
+	typeRawString Type = 7
 )
 
 // String returns string representation of t.
-func (t vType) String() string {
+func (t Type) String() string {
 	switch t {
-	case typeObject:
+	case TypeObject:
 		return "object"
-	case typeArray:
+	case TypeArray:
 		return "array"
-	case typeString:
+	case TypeString:
 		return "string"
-	case typeNumber:
+	case TypeNumber:
 		return "number"
-	case typeTrue:
+	case TypeTrue:
 		return "true"
-	case typeFalse:
+	case TypeFalse:
 		return "false"
-	case typeNull:
+	case TypeNull:
 		return "null"
 
 	// typeRawString is skipped intentionally,
@@ -137,7 +215,18 @@ func (t vType) String() string {
 // maxDepth is the maximum depth for nested JSON.
 const maxDepth = 300
 
+// parseValue parses the value at the start of s and records its source span.
 func parseValue(s string, c *cache, depth int) (*Value, string, error) {
+	v, tail, err := parseValueContent(s, c, depth)
+	if err != nil {
+		return nil, tail, err
+	}
+	v.raw = s[:len(s)-len(tail)]
+	v.off = c.base + c.n - len(s)
+	return v, tail, nil
+}
+
+func parseValueContent(s string, c *cache, depth int) (*Value, string, error) {
 	if len(s) == 0 {
 		return nil, s, fmt.Errorf("cannot parse empty string")
 	}
@@ -174,26 +263,32 @@ func parseValue(s string, c *cache, depth int) (*Value, string, error) {
 		if len(s) < len("true") || s[:len("true")] != "true" {
 			return nil, s, fmt.Errorf("unexpected value found: %q", s)
 		}
-		return valueTrue, s[len("true"):], nil
+		v := c.getValue()
+		v.t = TypeTrue
+		return v, s[len("true"):], nil
 	}
 	if s[0] == 'f' {
 		if len(s) < len("false") || s[:len("false")] != "false" {
 			return nil, s, fmt.Errorf("unexpected value found: %q", s)
 		}
-		return valueFalse, s[len("false"):], nil
+		v := c.getValue()
+		v.t = TypeFalse
+		return v, s[len("false"):], nil
 	}
 	if s[0] == 'n' {
 		if len(s) < len("null") || s[:len("null")] != "null" {
 			// Try parsing NaN
 			if len(s) >= 3 && strings.EqualFold(s[:3], "nan") {
 				v := c.getValue()
-				v.t = typeNumber
+				v.t = TypeNumber
 				v.s = s[:3]
 				return v, s[3:], nil
 			}
 			return nil, s, fmt.Errorf("unexpected value found: %q", s)
 		}
-		return valueNull, s[len("null"):], nil
+		v := c.getValue()
+		v.t = TypeNull
+		return v, s[len("null"):], nil
 	}
 
 	ns, tail, err := parseRawNumber(s)
@@ -201,7 +296,7 @@ func parseValue(s string, c *cache, depth int) (*Value, string, error) {
 		return nil, tail, fmt.Errorf("cannot parse number: %s", err)
 	}
 	v := c.getValue()
-	v.t = typeNumber
+	v.t = TypeNumber
 	v.s = ns
 	return v, tail, nil
 }
@@ -214,13 +309,13 @@ func parseArray(s string, c *cache, depth int) (*Value, string, error) {
 
 	if s[0] == ']' {
 		v := c.getValue()
-		v.t = typeArray
+		v.t = TypeArray
 		v.a = v.a[:0]
 		return v, s[1:], nil
 	}
 
 	a := c.getValue()
-	a.t = typeArray
+	a.t = TypeArray
 	a.a = a.a[:0]
 	for {
 		var v *Value
@@ -257,13 +352,13 @@ func parseObject(s string, c *cache, depth int) (*Value, string, error) {
 
 	if s[0] == '}' {
 		v := c.getValue()
-		v.t = typeObject
+		v.t = TypeObject
 		v.o.reset()
 		return v, s[1:], nil
 	}
 
 	o := c.getValue()
-	o.t = typeObject
+	o.t = TypeObject
 	o.o.reset()
 	for {
 		var err error
@@ -315,7 +410,7 @@ func escapeString(dst []byte, s string) []byte {
 	}
 
 	// Slow path.
-	return strconv.AppendQuote(dst, s)
+	return appendJSONString(dst, s, 0)
 }
 
 func hasSpecialChars(s string) bool {
@@ -330,16 +425,78 @@ func hasSpecialChars(s string) bool {
 	return false
 }
 
-func unescapeStringBestEffort(s string) string {
+// isValidRawString returns true if s may be put between quotes as is
+// in order to get valid JSON string.
+func isValidRawString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 {
+			return false
+		}
+		if c != '\\' {
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return false
+		}
+		switch s[i] {
+		case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		case 'u':
+			if i+5 > len(s) {
+				return false
+			}
+			for j := i + 1; j < i+5; j++ {
+				if !isHexDigit(s[j]) {
+					return false
+				}
+			}
+			i += 4
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// unescapeStringBestEffort unescapes s with UTF8Keep policy.
+//
+// It returns true if lone surrogate escapes are stored in the result as literal text.
+func unescapeStringBestEffort(s string) (string, bool) {
+	s, loneSurrogates, _ := unescape(s, UTF8Keep)
+	return s, loneSurrogates
+}
+
+// unescapeString unescapes JSON string contents s according to policy.
+//
+// Invalid escape sequences are stored unchanged. Lone surrogates and
+// invalid UTF-8 bytes are handled according to policy.
+// An error may be returned only for UTF8Error policy.
+func unescapeString(s string, policy UTF8Policy) (string, error) {
+	s, _, err := unescape(s, policy)
+	return s, err
+}
+
+// unescape is like unescapeString, but also returns true if lone surrogate
+// escapes are stored in the result as literal text according to UTF8Keep policy.
+func unescape(s string, policy UTF8Policy) (string, bool, error) {
 	n := strings.IndexByte(s, '\\')
 	if n < 0 {
 		// Fast path - nothing to unescape.
-		return s
+		s, err := sanitizeUTF8(s, policy)
+		return s, false, err
 	}
+	loneSurrogates := false
 
 	// Slow path - unescape string.
-	b := s2b(s) // It is safe to do, since s points to a byte slice in parser.b.
-	b = b[:n]
+	// Unescape into a new buffer instead of parser.b, so source spans
+	// of the parsed values remain intact. See Value.Raw.
+	b := make([]byte, n, len(s))
+	copy(b, s)
 	s = s[n+1:]
 	for len(s) > 0 {
 		ch := s[0]
@@ -382,20 +539,27 @@ func unescapeStringBestEffort(s string) string {
 
 			// Surrogate.
 			// See https://en.wikipedia.org/wiki/Universal_Character_Set_characters#Surrogates
-			if len(s) < 6 || s[0] != '\\' || s[1] != 'u' {
-				b = append(b, "\\u"...)
-				b = append(b, xs...)
-				break
+			if len(s) >= 6 && s[0] == '\\' && s[1] == 'u' {
+				if x1, err := strconv.ParseUint(s[2:6], 16, 16); err == nil {
+					if r := utf16.DecodeRune(rune(x), rune(x1)); r != utf8.RuneError {
+						b = append(b, string(r)...)
+						s = s[6:]
+						break
+					}
+				}
 			}
-			x1, err := strconv.ParseUint(s[2:6], 16, 16)
-			if err != nil {
+
+			// Lone surrogate. The next escape sequence, if any, is processed separately.
+			switch policy {
+			case UTF8Replace:
+				b = append(b, string(utf8.RuneError)...)
+			case UTF8Error:
+				return "", false, fmt.Errorf("lone surrogate \\u%s in string", xs)
+			default:
 				b = append(b, "\\u"...)
 				b = append(b, xs...)
-				break
+				loneSurrogates = true
 			}
-			r := utf16.DecodeRune(rune(x), rune(x1))
-			b = append(b, string(r)...)
-			s = s[6:]
 		default:
 			// Unknown escape sequence. Just store it unchanged.
 			b = append(b, '\\', ch)
@@ -408,7 +572,8 @@ func unescapeStringBestEffort(s string) string {
 		b = append(b, s[:n]...)
 		s = s[n+1:]
 	}
-	return b2s(b)
+	us, err := sanitizeUTF8(b2s(b), policy)
+	return us, loneSurrogates, err
 }
 
 // parseRawKey is similar to parseRawString, but is optimized
@@ -491,16 +656,25 @@ type kv struct {
 
 // Object represents JSON object.
 //
-// Object cannot be used from concurrent goroutines.
-// Use per-goroutine parsers or ParserPool instead.
+// Object cannot be used from concurrent goroutines unless it is frozen.
+// See Value.Freeze.
 type Object struct {
 	kvs           []kv
 	keysUnescaped bool
+
+	// loneSurrogateKeys is set when lone surrogate escapes are stored in keys
+	// as literal text during the default unescaping. See UTF8Keep.
+	loneSurrogateKeys bool
+
+	// frozen is set by Value.Freeze.
+	frozen bool
 }
 
 func (o *Object) reset() {
 	o.kvs = o.kvs[:0]
 	o.keysUnescaped = false
+	o.loneSurrogateKeys = false
+	o.frozen = false
 }
 
 func (o *Object) getKV() *kv {
@@ -519,13 +693,25 @@ func (o *Object) unescapeKeys() {
 	kvs := o.kvs
 	for i := range kvs {
 		kv := &kvs[i]
-		kv.k = unescapeStringBestEffort(kv.k)
+		var loneSurrogates bool
+		kv.k, loneSurrogates = unescapeStringBestEffort(kv.k)
+		if loneSurrogates {
+			o.loneSurrogateKeys = true
+		}
 	}
 	o.keysUnescaped = true
 }
 
 // marshalTo appends marshaled o to dst and returns the result.
 func (o *Object) marshalTo(dst []byte) []byte {
+	if !o.keysUnescaped {
+		for _, kv := range o.kvs {
+			if !isValidRawString(kv.k) {
+				o.unescapeKeys()
+				break
+			}
+		}
+	}
 	dst = append(dst, '{')
 	for i, kv := range o.kvs {
 		if o.keysUnescaped {
@@ -603,24 +789,95 @@ func (o *Object) MarshalString() string {
 	return b2s(b)
 }
 
+// MarshalJSON implements json.Marshaler.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	return o.marshalTo(nil), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// b must contain JSON object. The o doesn't reference b after returning.
+func (o *Object) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	if v.t != TypeObject {
+		return fmt.Errorf("value doesn't contain object; it contains %s", v.vType())
+	}
+	*o = v.o
+	return nil
+}
+
+// AsRawMessage returns marshaled o as json.RawMessage.
+func (o *Object) AsRawMessage() json.RawMessage {
+	return o.marshalTo(nil)
+}
+
 // Value represents any JSON value.
 //
-// Call vType in order to determine the actual type of the JSON value.
+// Call Type in order to determine the actual type of the JSON value.
 //
-// Value cannot be used from concurrent goroutines.
-// Use per-goroutine parsers or ParserPool instead.
+// Value cannot be used from concurrent goroutines unless it is frozen.
+// See Value.Freeze.
 type Value struct {
 	o Object
 	a []*Value
 	s string
-	t vType
+	t Type
+
+	// frozen is set by Value.Freeze on arrays.
+	frozen bool
+
+	// loneSurrogates is set when lone surrogate escapes are stored in s
+	// as literal text during the default unescaping. See UTF8Keep.
+	loneSurrogates bool
+
+	// raw is the original text of the value set by the parser.
+	raw string
+
+	// off is the offset of raw in the input passed to Parse.
+	off int
+}
+
+// Type returns the type of the v.
+func (v *Value) Type() Type {
+	return v.vType()
+}
+
+// Raw returns the original text of the v as it appears in the parsed input,
+// including whitespace inside objects and arrays and escape sequences in strings.
+//
+// nil is returned for values, which weren't obtained from the parser,
+// such as values created by Arena or returned by Clone.
+// The text isn't updated when v is modified.
+//
+// The returned bytes must not be modified. They are valid until parse
+// is called on the parser returned v.
+func (v *Value) Raw() []byte {
+	if v == nil || len(v.raw) == 0 {
+		return nil
+	}
+	return s2b(v.raw)
+}
+
+// Offset returns the byte offsets of the start and the end of v
+// in the input passed to Parse, so input[start:end] equals v.Raw().
+//
+// Offsets account for the input skipped before partialKey.
+// (-1, -1) is returned if v has no source text. See Value.Raw.
+func (v *Value) Offset() (start, end int) {
+	if v == nil || len(v.raw) == 0 {
+		return -1, -1
+	}
+	return v.off, v.off + len(v.raw)
 }
 
 // vType returns the type of the v.
-func (v *Value) vType() vType {
+func (v *Value) vType() Type {
 	if v.t == typeRawString {
-		v.s = unescapeStringBestEffort(v.s)
-		v.t = typeString
+		v.s, v.loneSurrogates = unescapeStringBestEffort(v.s)
+		v.t = TypeString
 	}
 	return v.t
 }
@@ -629,13 +886,19 @@ func (v *Value) vType() vType {
 func (v *Value) marshalTo(dst []byte) []byte {
 	switch v.t {
 	case typeRawString:
+		if !isValidRawString(v.s) {
+			// The string contains chars or escape sequences, which are invalid in JSON.
+			// Unescape it and then escape it properly.
+			v.vType()
+			return escapeString(dst, v.s)
+		}
 		dst = append(dst, '"')
 		dst = append(dst, v.s...)
 		dst = append(dst, '"')
 		return dst
-	case typeObject:
+	case TypeObject:
 		return v.o.marshalTo(dst)
-	case typeArray:
+	case TypeArray:
 		dst = append(dst, '[')
 		for i, vv := range v.a {
 			dst = vv.marshalTo(dst)
@@ -645,15 +908,15 @@ func (v *Value) marshalTo(dst []byte) []byte {
 		}
 		dst = append(dst, ']')
 		return dst
-	case typeString:
+	case TypeString:
 		return escapeString(dst, v.s)
-	case typeNumber:
+	case TypeNumber:
 		return append(dst, v.s...)
-	case typeTrue:
+	case TypeTrue:
 		return append(dst, "true"...)
-	case typeFalse:
+	case TypeFalse:
 		return append(dst, "false"...)
-	case typeNull:
+	case TypeNull:
 		return append(dst, "null"...)
 	default:
 		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
@@ -680,12 +943,12 @@ func (v *Value) Get(keys ...string) *Value {
 	}
 	_v := v
 	for _, key := range keys {
-		if _v.t == typeObject {
+		if _v.t == TypeObject {
 			_v = _v.o.Get(key)
 			if _v == nil {
 				return nil
 			}
-		} else if _v.t == typeArray {
+		} else if _v.t == TypeArray {
 			n, err := strconv.Atoi(key)
 			if err != nil || n < 0 || n >= len(_v.a) {
 				return nil
@@ -707,7 +970,7 @@ func (v *Value) Get(keys ...string) *Value {
 // The returned object is valid until parse is called on the parser returned v.
 func (v *Value) GetObject(keys ...string) *Object {
 	r := v.Get(keys...)
-	if r == nil || r.t != typeObject {
+	if r == nil || r.t != TypeObject {
 		return nil
 	}
 	return &r.o
@@ -722,7 +985,7 @@ func (v *Value) GetObject(keys ...string) *Object {
 // The returned array is valid until parse is called on the parser returned v.
 func (v *Value) GetArray(keys ...string) []*Value {
 	r := v.Get(keys...)
-	if r == nil || r.t != typeArray {
+	if r == nil || r.t != TypeArray {
 		return nil
 	}
 	return r.a
@@ -735,7 +998,7 @@ func (v *Value) GetArray(keys ...string) []*Value {
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetFloat64(keys ...string) float64 {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeNumber {
+	if r == nil || r.vType() != TypeNumber {
 		return 0
 	}
 	return parseBestEffort(r.s)
@@ -748,7 +1011,7 @@ func (v *Value) GetFloat64(keys ...string) float64 {
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetInt(keys ...string) int {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeNumber {
+	if r == nil || r.vType() != TypeNumber {
 		return 0
 	}
 	n := parseInt64BestEffort(r.s)
@@ -766,7 +1029,7 @@ func (v *Value) GetInt(keys ...string) int {
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetUint(keys ...string) uint {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeNumber {
+	if r == nil || r.vType() != TypeNumber {
 		return 0
 	}
 	n := parseUint64BestEffort(r.s)
@@ -784,7 +1047,7 @@ func (v *Value) GetUint(keys ...string) uint {
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetInt64(keys ...string) int64 {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeNumber {
+	if r == nil || r.vType() != TypeNumber {
 		return 0
 	}
 	return parseInt64BestEffort(r.s)
@@ -797,7 +1060,7 @@ func (v *Value) GetInt64(keys ...string) int64 {
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetUint64(keys ...string) uint64 {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeNumber {
+	if r == nil || r.vType() != TypeNumber {
 		return 0
 	}
 	return parseUint64BestEffort(r.s)
@@ -805,7 +1068,7 @@ func (v *Value) GetUint64(keys ...string) uint64 {
 
 func (v *Value) GetString(keys ...string) string {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeString {
+	if r == nil || r.vType() != TypeString {
 		return ""
 	}
 	return r.s
@@ -820,7 +1083,7 @@ func (v *Value) GetString(keys ...string) string {
 // The returned string is valid until parse is called on the parser returned v.
 func (v *Value) GetStringBytes(keys ...string) []byte {
 	r := v.Get(keys...)
-	if r == nil || r.vType() != typeString {
+	if r == nil || r.vType() != TypeString {
 		return nil
 	}
 	return s2b(r.s)
@@ -833,7 +1096,7 @@ func (v *Value) GetStringBytes(keys ...string) []byte {
 // false is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetBool(keys ...string) bool {
 	r := v.Get(keys...)
-	if r != nil && r.t == typeTrue {
+	if r != nil && r.t == TypeTrue {
 		return true
 	}
 	return false
@@ -850,13 +1113,54 @@ func (v *Value) MarshalString() string {
 	return b2s(b)
 }
 
+// MarshalJSON implements json.Marshaler.
+//
+// nil v is marshaled to null.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return append([]byte(nil), "null"...), nil
+	}
+	return v.marshalTo(nil), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// The v doesn't reference b after returning.
+func (v *Value) UnmarshalJSON(b []byte) error {
+	if v == valueTrue || v == valueFalse || v == valueNull {
+		// Overwriting shared values would change them for the whole process.
+		return fmt.Errorf("cannot unmarshal JSON into shared %s value; use new Value instead", v.t)
+	}
+	vv, err := unmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	*v = *vv
+	return nil
+}
+
+// AsRawMessage returns marshaled v as json.RawMessage,
+// so it may be embedded into documents built with encoding/json.
+func (v *Value) AsRawMessage() json.RawMessage {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v.marshalTo(nil)
+}
+
+func unmarshalJSON(b []byte) (*Value, error) {
+	// parser copies b into its own buffer, so the returned value doesn't alias b.
+	p := &parser{}
+	return p.parse(b2s(b), 0)
+}
+
 // Object returns the underlying JSON object for the v.
 //
 // The returned object is valid until parse is called on the parser returned v.
 //
 // Use GetObject if you don't need error handling.
 func (v *Value) Object() (*Object, error) {
-	if v.t != typeObject {
+	if v.t != TypeObject {
 		return nil, fmt.Errorf("value doesn't contain object; it contains %s", v.vType())
 	}
 	return &v.o, nil
@@ -868,7 +1172,7 @@ func (v *Value) Object() (*Object, error) {
 //
 // Use GetArray if you don't need error handling.
 func (v *Value) Array() ([]*Value, error) {
-	if v.t != typeArray {
+	if v.t != TypeArray {
 		return nil, fmt.Errorf("value doesn't contain array; it contains %s", v.vType())
 	}
 	return v.a, nil
@@ -880,14 +1184,14 @@ func (v *Value) Array() ([]*Value, error) {
 //
 // Use GetStringBytes if you don't need error handling.
 func (v *Value) StringBytes() ([]byte, error) {
-	if v.vType() != typeString {
+	if v.vType() != TypeString {
 		return nil, fmt.Errorf("value doesn't contain string; it contains %s", v.vType())
 	}
 	return s2b(v.s), nil
 }
 
 func (v *Value) String() (string, error) {
-	if v.vType() != typeString {
+	if v.vType() != TypeString {
 		return "", fmt.Errorf("value doesn't contain string; it contains %s", v.vType())
 	}
 	return v.s, nil
@@ -897,7 +1201,7 @@ func (v *Value) String() (string, error) {
 //
 // Use GetFloat64 if you don't need error handling.
 func (v *Value) Float64() (float64, error) {
-	if v.vType() != typeNumber {
+	if v.vType() != TypeNumber {
 		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
 	}
 	return parse(v.s)
@@ -907,7 +1211,7 @@ func (v *Value) Float64() (float64, error) {
 //
 // Use GetInt if you don't need error handling.
 func (v *Value) Int() (int, error) {
-	if v.vType() != typeNumber {
+	if v.vType() != TypeNumber {
 		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
 	}
 	n, err := parseInt64(v.s)
@@ -925,7 +1229,7 @@ func (v *Value) Int() (int, error) {
 //
 // Use GetInt if you don't need error handling.
 func (v *Value) Uint() (uint, error) {
-	if v.vType() != typeNumber {
+	if v.vType() != TypeNumber {
 		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
 	}
 	n, err := parseUint64(v.s)
@@ -943,7 +1247,7 @@ func (v *Value) Uint() (uint, error) {
 //
 // Use GetInt64 if you don't need error handling.
 func (v *Value) Int64() (int64, error) {
-	if v.vType() != typeNumber {
+	if v.vType() != TypeNumber {
 		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
 	}
 	return parseInt64(v.s)
@@ -953,7 +1257,7 @@ func (v *Value) Int64() (int64, error) {
 //
 // Use GetInt64 if you don't need error handling.
 func (v *Value) Uint64() (uint64, error) {
-	if v.vType() != typeNumber {
+	if v.vType() != TypeNumber {
 		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
 	}
 	return parseUint64(v.s)
@@ -963,19 +1267,19 @@ func (v *Value) Uint64() (uint64, error) {
 //
 // Use GetBool if you don't need error handling.
 func (v *Value) Bool() (bool, error) {
-	if v.t == typeTrue {
+	if v.t == TypeTrue {
 		return true, nil
 	}
-	if v.t == typeFalse {
+	if v.t == TypeFalse {
 		return false, nil
 	}
 	return false, fmt.Errorf("value doesn't contain bool; it contains %s", v.vType())
 }
 
 var (
-	valueTrue  = &Value{t: typeTrue}
-	valueFalse = &Value{t: typeFalse}
-	valueNull  = &Value{t: typeNull}
+	valueTrue  = &Value{t: TypeTrue}
+	valueFalse = &Value{t: TypeFalse}
+	valueNull  = &Value{t: TypeNull}
 )
 
 // parseBestEffort parses floating-point number s.
@@ -0,0 +1,324 @@
+package jsonpart
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MarshalOptions contains options for MarshalWith.
+//
+// Zero MarshalOptions produces compact output.
+type MarshalOptions struct {
+	// Prefix starts every new line of the indented output.
+	Prefix string
+
+	// Indent is written once per nesting level of the indented output.
+	//
+	// The output is indented if Prefix or Indent is non-empty.
+	Indent string
+
+	// SortKeys sorts object keys in byte order instead of keeping
+	// the original order.
+	SortKeys bool
+
+	// EscapeHTML escapes <, > and & in strings as \u003c, \u003e and \u0026,
+	// so the output may be embedded into HTML.
+	EscapeHTML bool
+
+	// ASCIIOnly escapes all the non-ASCII chars in strings with \u escapes.
+	ASCIIOnly bool
+
+	// EscapeLineTerminators escapes U+2028 and U+2029 in strings,
+	// so the output may be embedded into JavaScript code such as <script> contents.
+	EscapeLineTerminators bool
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 bytes and lone surrogate
+	// escapes in strings and object keys with U+FFFD replacement char,
+	// so the output is always well-formed UTF-8 text.
+	ReplaceInvalidUTF8 bool
+
+	// TrailingNewline appends '\n' to the output.
+	TrailingNewline bool
+}
+
+// MarshalIndent returns marshaled v, where every JSON element begins
+// on a new line starting with prefix followed by one or more copies
+// of indent according to the nesting level.
+func (v *Value) MarshalIndent(prefix, indent string) []byte {
+	return v.MarshalWith(MarshalOptions{
+		Prefix: prefix,
+		Indent: indent,
+	})
+}
+
+// MarshalWith returns marshaled v according to opts.
+func (v *Value) MarshalWith(opts MarshalOptions) []byte {
+	e := newEncoder(opts)
+	dst := e.value(nil, v, 0)
+	if opts.TrailingNewline {
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// MarshalIndent returns marshaled o, where every JSON element begins
+// on a new line starting with prefix followed by one or more copies
+// of indent according to the nesting level.
+func (o *Object) MarshalIndent(prefix, indent string) []byte {
+	return o.MarshalWith(MarshalOptions{
+		Prefix: prefix,
+		Indent: indent,
+	})
+}
+
+// MarshalWith returns marshaled o according to opts.
+func (o *Object) MarshalWith(opts MarshalOptions) []byte {
+	e := newEncoder(opts)
+	dst := e.object(nil, o, 0)
+	if opts.TrailingNewline {
+		dst = append(dst, '\n')
+	}
+	return dst
+}
+
+// encoder marshals values according to MarshalOptions.
+type encoder struct {
+	opts     MarshalOptions
+	mode     escapeMode
+	indented bool
+}
+
+func newEncoder(opts MarshalOptions) *encoder {
+	e := &encoder{
+		opts:     opts,
+		indented: opts.Prefix != "" || opts.Indent != "",
+	}
+	if opts.EscapeHTML {
+		e.mode |= escapeHTML
+	}
+	if opts.ASCIIOnly {
+		e.mode |= escapeASCII
+	}
+	if opts.EscapeLineTerminators {
+		e.mode |= escapeLineTerminators
+	}
+	if opts.ReplaceInvalidUTF8 {
+		e.mode |= escapeInvalidUTF8
+	}
+	return e
+}
+
+func (e *encoder) value(dst []byte, v *Value, depth int) []byte {
+	if v == nil {
+		return append(dst, "null"...)
+	}
+	if v.t == typeRawString && e.opts.ReplaceInvalidUTF8 {
+		// Lone surrogates cannot be detected after the default unescaping,
+		// so unescape the string without modifying v.
+		s, _ := unescapeString(v.s, UTF8Replace)
+		return appendJSONString(dst, s, e.mode)
+	}
+	if v.t == TypeString && v.loneSurrogates && e.opts.ReplaceInvalidUTF8 {
+		// The string has been already unescaped with literal lone surrogates.
+		// Unescape the source text again if it is available, since it is exact.
+		var s string
+		if len(v.raw) >= 2 {
+			s, _ = unescapeString(v.raw[1:len(v.raw)-1], UTF8Replace)
+		} else {
+			s = replaceLoneSurrogates(v.s)
+		}
+		return appendJSONString(dst, s, e.mode)
+	}
+	switch v.vType() {
+	case TypeObject:
+		return e.object(dst, &v.o, depth)
+	case TypeArray:
+		if len(v.a) == 0 {
+			return append(dst, "[]"...)
+		}
+		dst = append(dst, '[')
+		for i, vv := range v.a {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = e.newline(dst, depth+1)
+			dst = e.value(dst, vv, depth+1)
+		}
+		dst = e.newline(dst, depth)
+		return append(dst, ']')
+	case TypeString:
+		return appendJSONString(dst, v.s, e.mode)
+	case TypeNumber:
+		return append(dst, v.s...)
+	case TypeTrue:
+		return append(dst, "true"...)
+	case TypeFalse:
+		return append(dst, "false"...)
+	case TypeNull:
+		return append(dst, "null"...)
+	default:
+		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
+	}
+}
+
+func (e *encoder) object(dst []byte, o *Object, depth int) []byte {
+	if o == nil {
+		return append(dst, "null"...)
+	}
+	if len(o.kvs) == 0 {
+		return append(dst, "{}"...)
+	}
+	kvs := o.kvs
+	if !o.keysUnescaped && e.opts.ReplaceInvalidUTF8 {
+		// Unescape keys without modifying o like in encoder.value.
+		kvs = make([]kv, len(o.kvs))
+		for i, kv := range o.kvs {
+			kvs[i].k, _ = unescapeString(kv.k, UTF8Replace)
+			kvs[i].v = kv.v
+		}
+	} else {
+		o.unescapeKeys()
+		if o.loneSurrogateKeys && e.opts.ReplaceInvalidUTF8 {
+			kvs = make([]kv, len(o.kvs))
+			for i, kv := range o.kvs {
+				kvs[i].k = replaceLoneSurrogates(kv.k)
+				kvs[i].v = kv.v
+			}
+		}
+	}
+	if e.opts.SortKeys {
+		kvs = append([]kv(nil), kvs...)
+		sort.SliceStable(kvs, func(i, j int) bool {
+			return kvs[i].k < kvs[j].k
+		})
+	}
+	dst = append(dst, '{')
+	for i, kv := range kvs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = e.newline(dst, depth+1)
+		dst = appendJSONString(dst, kv.k, e.mode)
+		dst = append(dst, ':')
+		if e.indented {
+			dst = append(dst, ' ')
+		}
+		dst = e.value(dst, kv.v, depth+1)
+	}
+	dst = e.newline(dst, depth)
+	return append(dst, '}')
+}
+
+func (e *encoder) newline(dst []byte, depth int) []byte {
+	if !e.indented {
+		return dst
+	}
+	dst = append(dst, '\n')
+	dst = append(dst, e.opts.Prefix...)
+	for i := 0; i < depth; i++ {
+		dst = append(dst, e.opts.Indent...)
+	}
+	return dst
+}
+
+// escapeMode is a set of additional escaping rules for appendJSONString.
+type escapeMode uint8
+
+const (
+	// escapeHTML escapes <, > and &.
+	escapeHTML escapeMode = 1 << iota
+
+	// escapeASCII escapes all the non-ASCII chars.
+	escapeASCII
+
+	// escapeLineTerminators escapes U+2028 and U+2029.
+	escapeLineTerminators
+
+	// escapeInvalidUTF8 escapes invalid UTF-8 bytes as \ufffd.
+	escapeInvalidUTF8
+)
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s quoted as JSON string to dst.
+//
+// Only '"', '\\' and control chars are escaped by default as RFC 8259 requires.
+// Additional escaping rules may be enabled via mode.
+func appendJSONString(dst []byte, s string, mode escapeMode) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && (mode&escapeHTML == 0 || (c != '<' && c != '>' && c != '&')) {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = appendUnicodeEscape(dst, rune(c))
+			}
+			i++
+			start = i
+			continue
+		}
+		if mode&escapeASCII == 0 {
+			// U+2028 and U+2029 are encoded as E2 80 A8 and E2 80 A9.
+			if mode&escapeLineTerminators != 0 && c == 0xe2 && i+2 < len(s) && s[i+1] == 0x80 && s[i+2]&^1 == 0xa8 {
+				dst = append(dst, s[start:i]...)
+				dst = appendUnicodeEscape(dst, 0x2028+rune(s[i+2]&1))
+				i += 3
+				start = i
+				continue
+			}
+			if mode&escapeInvalidUTF8 == 0 {
+				i++
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r != utf8.RuneError || size != 1 {
+				i += size
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			dst = appendUnicodeEscape(dst, utf8.RuneError)
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		dst = append(dst, s[start:i]...)
+		if r >= 0x10000 {
+			r1, r2 := utf16.EncodeRune(r)
+			dst = appendUnicodeEscape(dst, r1)
+			dst = appendUnicodeEscape(dst, r2)
+		} else {
+			// Invalid UTF-8 bytes are decoded as utf8.RuneError,
+			// which is escaped as \ufffd.
+			dst = appendUnicodeEscape(dst, r)
+		}
+		i += size
+		start = i
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+func appendUnicodeEscape(dst []byte, r rune) []byte {
+	return append(dst, '\\', 'u', hexDigits[(r>>12)&0xf], hexDigits[(r>>8)&0xf], hexDigits[(r>>4)&0xf], hexDigits[r&0xf])
+}
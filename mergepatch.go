@@ -0,0 +1,91 @@
+package jsonpart
+
+// MergePatch applies JSON Merge Patch (RFC 7386) to target and returns the result.
+//
+// Object entries with null values in patch delete the corresponding entries
+// from target, while other entries are merged recursively. Any other patch
+// value replaces target as a whole.
+//
+// target remains unchanged. The returned value doesn't reference target and patch.
+// nil target is treated as a missing value.
+func MergePatch(target, patch *Value) *Value {
+	if patch == nil {
+		return target.Clone()
+	}
+	if patch.vType() != TypeObject {
+		return patch.Clone()
+	}
+	var result *Value
+	if target != nil && target.vType() == TypeObject {
+		result = target.Clone()
+	} else {
+		result = &Value{t: TypeObject}
+		result.o.keysUnescaped = true
+	}
+	mergePatchObject(result, patch)
+	return result
+}
+
+// mergePatchObject applies object patch to the mutable object target in place.
+func mergePatchObject(target, patch *Value) {
+	patch.o.unescapeKeys()
+	for _, kv := range patch.o.kvs {
+		if kv.v.vType() == TypeNull {
+			target.o.Del(kv.k)
+			continue
+		}
+		if kv.v.t != TypeObject {
+			target.o.Set(kv.k, kv.v.Clone())
+			continue
+		}
+		// target is a clone, so its child objects may be modified in place.
+		tv := target.o.Get(kv.k)
+		if tv == nil || tv.vType() != TypeObject {
+			tv = &Value{t: TypeObject}
+			tv.o.keysUnescaped = true
+			target.o.Set(kv.k, tv)
+		}
+		mergePatchObject(tv, kv.v)
+	}
+}
+
+// CreateMergePatch returns JSON Merge Patch (RFC 7386), which turns a into b.
+//
+// Objects are diffed recursively, while other values are replaced as a whole.
+// Since null in merge patch means deletion, object entries with null values
+// in b cannot be represented and are deleted by the returned patch.
+//
+// The returned patch doesn't reference a and b.
+func CreateMergePatch(a, b *Value) *Value {
+	if a == nil || b == nil || a.vType() != TypeObject || b.vType() != TypeObject {
+		if b == nil {
+			return newNull()
+		}
+		return b.Clone()
+	}
+	patch := &Value{t: TypeObject}
+	patch.o.keysUnescaped = true
+	a.o.unescapeKeys()
+	b.o.unescapeKeys()
+	for _, kv := range a.o.kvs {
+		if b.o.Get(kv.k) == nil {
+			patch.o.Set(kv.k, newNull())
+		}
+	}
+	for _, kv := range b.o.kvs {
+		av := a.o.Get(kv.k)
+		if av == nil {
+			patch.o.Set(kv.k, kv.v.Clone())
+			continue
+		}
+		if EqualWith(av, kv.v, diffOptions) {
+			continue
+		}
+		if av.vType() == TypeObject && kv.v.vType() == TypeObject {
+			patch.o.Set(kv.k, CreateMergePatch(av, kv.v))
+			continue
+		}
+		patch.o.Set(kv.k, kv.v.Clone())
+	}
+	return patch
+}
@@ -0,0 +1,189 @@
+package jsonpart
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxBigExponent limits exponents of numbers converted by Value.BigInt
+// and Value.Rat, since big.Int and big.Rat allocate memory proportional
+// to the exponent.
+const maxBigExponent = 10000
+
+// Number returns the original text of JSON number v.
+//
+// The text is returned as is, without precision loss.
+// Empty json.Number is returned if v isn't a number.
+func (v *Value) Number() json.Number {
+	if v.vType() != TypeNumber {
+		return ""
+	}
+	return json.Number(v.s)
+}
+
+// GetNumber returns the original text of JSON number by the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// Empty json.Number is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetNumber(keys ...string) json.Number {
+	r := v.Get(keys...)
+	if r == nil {
+		return ""
+	}
+	return r.Number()
+}
+
+// BigInt returns the underlying JSON number for the v as arbitrary-precision integer.
+//
+// Numbers with fractional part or exponent are accepted if their value
+// is integer, e.g. 1.5e3. An error is returned for non-integer values.
+func (v *Value) BigInt() (*big.Int, error) {
+	s, err := v.numberText()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse integer from %q", s)
+		}
+		return n, nil
+	}
+	r, err := parseBigRat(s)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, fmt.Errorf("number %q isn't integer", s)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// BigFloat returns the underlying JSON number for the v as arbitrary-precision float.
+//
+// The precision of the returned number is sufficient for holding
+// all the decimal digits of the number text.
+func (v *Value) BigFloat() (*big.Float, error) {
+	s, err := v.numberText()
+	if err != nil {
+		return nil, err
+	}
+	return parseBigFloat(s)
+}
+
+// Rat returns the exact value of the underlying JSON number for the v.
+//
+// Use it for decimal values such as prices, which cannot be represented
+// by float64 exactly.
+func (v *Value) Rat() (*big.Rat, error) {
+	s, err := v.numberText()
+	if err != nil {
+		return nil, err
+	}
+	return parseBigRat(s)
+}
+
+// numberText returns the text of v if it is valid JSON number.
+//
+// The parser accepts broader syntax for numbers such as inf and nan,
+// which cannot be converted to arbitrary-precision numbers.
+func (v *Value) numberText() (string, error) {
+	if v.vType() != TypeNumber {
+		return "", fmt.Errorf("value doesn't contain number; it contains %s", v.vType())
+	}
+	if !isJSONNumber(v.s) {
+		return "", fmt.Errorf("cannot parse number from %q", v.s)
+	}
+	return v.s, nil
+}
+
+func parseBigFloat(s string) (*big.Float, error) {
+	// Reserve enough mantissa bits for all the decimal digits in s.
+	prec := uint(len(s)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse number from %q: %w", s, err)
+	}
+	return f, nil
+}
+
+func parseBigRat(s string) (*big.Rat, error) {
+	if n := strings.IndexAny(s, "eE"); n >= 0 {
+		exp, err := strconv.Atoi(s[n+1:])
+		if err != nil || exp > maxBigExponent || exp < -maxBigExponent {
+			return nil, fmt.Errorf("exponent of number %q is out of range [%d..%d]", s, -maxBigExponent, maxBigExponent)
+		}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("cannot parse number from %q", s)
+	}
+	return r, nil
+}
+
+// isJSONNumber returns true if s matches the number grammar from RFC 8259.
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		n := skipDigits(s, i+1)
+		if n == i+1 {
+			return false
+		}
+		i = n
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		n := skipDigits(s, i)
+		if n == i {
+			return false
+		}
+		i = n
+	}
+	return i == len(s)
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// Unmarshal decodes v into dst via encoding/json.
+//
+// Numbers are decoded without precision loss: *big.Int fields receive
+// the exact integer, while any fields receive json.Number.
+// Unknown fields are ignored like in json.Unmarshal.
+func (v *Value) Unmarshal(dst any) error {
+	if v == nil {
+		return fmt.Errorf("cannot unmarshal nil value")
+	}
+	d := json.NewDecoder(bytes.NewReader(v.marshalTo(nil)))
+	d.UseNumber()
+	if err := d.Decode(dst); err != nil {
+		return fmt.Errorf("cannot unmarshal %s into %T: %w", v.vType(), dst, err)
+	}
+	return nil
+}
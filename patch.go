@@ -0,0 +1,314 @@
+package jsonpart
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPatchTestFailed is returned by ApplyPatch when a test operation fails.
+//
+// Use errors.Is for checking the returned error.
+var ErrPatchTestFailed = errors.New("test operation failed")
+
+// ParsePointer parses JSON Pointer (RFC 6901) into the keys path.
+//
+// The empty pointer refers to the whole document and results in empty path.
+func ParsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", p)
+	}
+	path := strings.Split(p[1:], "/")
+	for i, key := range path {
+		if strings.IndexByte(key, '~') < 0 {
+			continue
+		}
+		for j := 0; j < len(key); j++ {
+			if key[j] == '~' && (j+1 >= len(key) || (key[j+1] != '0' && key[j+1] != '1')) {
+				return nil, fmt.Errorf("invalid escape sequence in JSON pointer %q", p)
+			}
+		}
+		key = strings.ReplaceAll(key, "~1", "/")
+		path[i] = strings.ReplaceAll(key, "~0", "~")
+	}
+	return path, nil
+}
+
+// FormatPointer returns JSON Pointer (RFC 6901) for the given keys path.
+func FormatPointer(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		b.WriteByte('/')
+		if strings.ContainsAny(key, "~/") {
+			key = strings.ReplaceAll(key, "~", "~0")
+			key = strings.ReplaceAll(key, "/", "~1")
+		}
+		b.WriteString(key)
+	}
+	return b.String()
+}
+
+// CreatePatch returns JSON Patch (RFC 6902) document, which turns a into b.
+//
+// The patch is built from the changes returned by Diff,
+// so it contains only add, remove and replace operations.
+// The returned patch doesn't reference a and b.
+func CreatePatch(a, b *Value) *Value {
+	var ar Arena
+	patch := ar.NewArray()
+	for _, c := range Diff(a, b) {
+		op := ar.NewObject()
+		switch c.Kind {
+		case ChangeAdded:
+			op.Set("op", ar.NewString("add"))
+		case ChangeRemoved:
+			op.Set("op", ar.NewString("remove"))
+		default:
+			op.Set("op", ar.NewString("replace"))
+		}
+		op.Set("path", ar.NewString(FormatPointer(c.Path)))
+		if c.New != nil {
+			op.Set("value", c.New.Clone())
+		}
+		patch.Append(op)
+	}
+	return patch
+}
+
+// ApplyPatch applies JSON Patch (RFC 6902) document to v and returns the result.
+//
+// All the operations are applied to a copy of v, so v remains unchanged.
+// Nothing is returned if any operation fails. The returned error
+// wraps ErrPatchTestFailed if a test operation fails.
+func ApplyPatch(v *Value, patch *Value) (*Value, error) {
+	if v == nil {
+		return nil, fmt.Errorf("cannot apply patch to nil value")
+	}
+	ops, err := patch.Array()
+	if err != nil {
+		return nil, fmt.Errorf("patch must be an array of operations: %s", err)
+	}
+	doc := v.Clone()
+	for i, op := range ops {
+		doc, err = applyPatchOp(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply operation #%d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyPatchOp(doc, op *Value) (*Value, error) {
+	if op.vType() != TypeObject {
+		return nil, fmt.Errorf("operation must be an object; it contains %s", op.vType())
+	}
+	name, err := patchOpString(op, "op")
+	if err != nil {
+		return nil, err
+	}
+	pointer, err := patchOpString(op, "path")
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", name, err)
+	}
+	path, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %s", name, err)
+	}
+
+	switch name {
+	case "add", "replace", "test":
+		value := op.Get("value")
+		if value == nil {
+			return nil, fmt.Errorf("%q at %q: missing \"value\"", name, pointer)
+		}
+		switch name {
+		case "add":
+			doc, err = patchAdd(doc, path, value.Clone())
+		case "replace":
+			doc, err = patchReplace(doc, path, value.Clone())
+		default:
+			var target *Value
+			target, err = patchGet(doc, path)
+			if err == nil && !EqualWith(target, value, diffOptions) {
+				err = fmt.Errorf("%w: value %s isn't equal to %s", ErrPatchTestFailed, startEndString(target.MarshalString()), startEndString(value.MarshalString()))
+			}
+		}
+	case "remove":
+		doc, _, err = patchRemove(doc, path)
+	case "move", "copy":
+		var fromPointer string
+		fromPointer, err = patchOpString(op, "from")
+		if err != nil {
+			return nil, fmt.Errorf("%q at %q: %s", name, pointer, err)
+		}
+		var from []string
+		from, err = ParsePointer(fromPointer)
+		if err != nil {
+			return nil, fmt.Errorf("%q at %q: %s", name, pointer, err)
+		}
+		var value *Value
+		if name == "move" {
+			if len(path) > len(from) && isPathPrefix(from, path) {
+				return nil, fmt.Errorf("%q at %q: cannot move value into its own child %q", name, fromPointer, pointer)
+			}
+			doc, value, err = patchRemove(doc, from)
+		} else {
+			value, err = patchGet(doc, from)
+			value = value.Clone()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%q from %q: %w", name, fromPointer, err)
+		}
+		doc, err = patchAdd(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q at %q: %w", name, pointer, err)
+	}
+	return doc, nil
+}
+
+func patchOpString(op *Value, key string) (string, error) {
+	v := op.Get(key)
+	if v == nil {
+		return "", fmt.Errorf("missing %q", key)
+	}
+	s, err := v.String()
+	if err != nil {
+		return "", fmt.Errorf("invalid %q: %s", key, err)
+	}
+	return s, nil
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	for i, key := range prefix {
+		if path[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+// patchGet returns the value at the given path in doc.
+func patchGet(doc *Value, path []string) (*Value, error) {
+	v := doc
+	for i, key := range path {
+		switch v.vType() {
+		case TypeObject:
+			vv := v.o.Get(key)
+			if vv == nil {
+				return nil, fmt.Errorf("key %q not found at %q", key, FormatPointer(path[:i]))
+			}
+			v = vv
+		case TypeArray:
+			n, err := patchArrayIndex(key, len(v.a)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s at %q", err, FormatPointer(path[:i]))
+			}
+			v = v.a[n]
+		default:
+			return nil, fmt.Errorf("cannot find %q in %s at %q", key, v.vType(), FormatPointer(path[:i]))
+		}
+	}
+	return v, nil
+}
+
+// patchArrayIndex parses array index key, which must be in the range [0..maxIdx].
+func patchArrayIndex(key string, maxIdx int) (int, error) {
+	if key == "-" {
+		return 0, fmt.Errorf("index \"-\" refers to nonexistent array item")
+	}
+	if key == "" || (len(key) > 1 && key[0] == '0') || strings.TrimLeft(key, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", key)
+	}
+	n, err := strconv.Atoi(key)
+	if err != nil || n > maxIdx {
+		return 0, fmt.Errorf("array index %q out of range [0..%d]", key, maxIdx)
+	}
+	return n, nil
+}
+
+func patchParent(doc *Value, path []string) (*Value, string, error) {
+	parent, err := patchGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	t := parent.vType()
+	if t != TypeObject && t != TypeArray {
+		return nil, "", fmt.Errorf("parent at %q is %s; it must be object or array", FormatPointer(path[:len(path)-1]), t)
+	}
+	return parent, path[len(path)-1], nil
+}
+
+func patchAdd(doc *Value, path []string, value *Value) (*Value, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, key, err := patchParent(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if parent.t == TypeObject {
+		parent.o.Set(key, value)
+		return doc, nil
+	}
+	if key == "-" {
+		parent.a = append(parent.a, value)
+		return doc, nil
+	}
+	n, err := patchArrayIndex(key, len(parent.a))
+	if err != nil {
+		return nil, err
+	}
+	parent.a = append(parent.a, nil)
+	copy(parent.a[n+1:], parent.a[n:])
+	parent.a[n] = value
+	return doc, nil
+}
+
+func patchReplace(doc *Value, path []string, value *Value) (*Value, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if _, err := patchGet(doc, path); err != nil {
+		return nil, err
+	}
+	parent, key, err := patchParent(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if parent.t == TypeObject {
+		parent.o.Set(key, value)
+		return doc, nil
+	}
+	n, _ := strconv.Atoi(key)
+	parent.a[n] = value
+	return doc, nil
+}
+
+// patchRemove removes the value at the given path from doc and returns it.
+func patchRemove(doc *Value, path []string) (*Value, *Value, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	value, err := patchGet(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	parent, key, err := patchParent(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if parent.t == TypeObject {
+		parent.o.Del(key)
+		return doc, value, nil
+	}
+	n, _ := strconv.Atoi(key)
+	parent.a = append(parent.a[:n], parent.a[n+1:]...)
+	return doc, value, nil
+}
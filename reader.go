@@ -0,0 +1,325 @@
+package jsonpart
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when the requested keys path doesn't exist.
+//
+// Use errors.Is for checking the returned error.
+var ErrNotFound = errors.New("value not found")
+
+// PathError records an error for the value at the given keys path.
+type PathError struct {
+	// Path is the keys path of the value.
+	Path []string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error interface.
+func (e *PathError) Error() string {
+	return fmt.Sprintf("cannot read %q: %s", FormatPointer(e.Path), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// GetIntOr returns int value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetIntOr(def int, keys ...string) int {
+	r := v.Get(keys...)
+	if r == nil {
+		return def
+	}
+	n, err := r.Int()
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// GetInt64Or returns int64 value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetInt64Or(def int64, keys ...string) int64 {
+	r := v.Get(keys...)
+	if r == nil {
+		return def
+	}
+	n, err := r.Int64()
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// GetUint64Or returns uint64 value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetUint64Or(def uint64, keys ...string) uint64 {
+	r := v.Get(keys...)
+	if r == nil {
+		return def
+	}
+	n, err := r.Uint64()
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// GetFloat64Or returns float64 value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetFloat64Or(def float64, keys ...string) float64 {
+	r := v.Get(keys...)
+	if r == nil {
+		return def
+	}
+	f, err := r.Float64()
+	if err != nil {
+		return def
+	}
+	return f
+}
+
+// GetStringOr returns string value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetStringOr(def string, keys ...string) string {
+	r := v.Get(keys...)
+	if r == nil || r.vType() != TypeString {
+		return def
+	}
+	return r.s
+}
+
+// GetBoolOr returns bool value by the given keys path.
+//
+// def is returned for non-existing keys path or for invalid value type.
+func (v *Value) GetBoolOr(def bool, keys ...string) bool {
+	r := v.Get(keys...)
+	if r == nil {
+		return def
+	}
+	b, err := r.Bool()
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Reader reads many values from v while collecting errors.
+//
+// Reader methods return zero values on errors, so a batch of fields
+// may be read without per-field error handling. Check Err or Errs
+// after the batch.
+//
+// Reader cannot be used from concurrent goroutines.
+type Reader struct {
+	v    *Value
+	errs []error
+}
+
+// NewReader returns Reader for v.
+func NewReader(v *Value) *Reader {
+	return &Reader{v: v}
+}
+
+// Err returns the first error recorded by r.
+//
+// nil is returned if all the reads succeeded.
+func (r *Reader) Err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return r.errs[0]
+}
+
+// Errs returns all the errors recorded by r.
+//
+// Every error is *PathError.
+func (r *Reader) Errs() []error {
+	return r.errs
+}
+
+// Reset clears the errors recorded by r and makes it reading v.
+func (r *Reader) Reset(v *Value) {
+	r.v = v
+	r.errs = r.errs[:0]
+}
+
+// Value returns the value by the given keys path.
+//
+// ErrNotFound is recorded for non-existing keys path.
+func (r *Reader) Value(keys ...string) *Value {
+	v := r.v.Get(keys...)
+	if v == nil {
+		r.record(keys, ErrNotFound)
+	}
+	return v
+}
+
+// Exists returns true if the value by the given keys path exists.
+//
+// It doesn't record errors.
+func (r *Reader) Exists(keys ...string) bool {
+	return r.v.Exists(keys...)
+}
+
+// Object returns object value by the given keys path.
+func (r *Reader) Object(keys ...string) *Object {
+	v := r.Value(keys...)
+	if v == nil {
+		return nil
+	}
+	o, err := v.Object()
+	r.check(keys, err)
+	return o
+}
+
+// Array returns array value by the given keys path.
+func (r *Reader) Array(keys ...string) []*Value {
+	v := r.Value(keys...)
+	if v == nil {
+		return nil
+	}
+	a, err := v.Array()
+	r.check(keys, err)
+	return a
+}
+
+// String returns string value by the given keys path.
+func (r *Reader) String(keys ...string) string {
+	v := r.Value(keys...)
+	if v == nil {
+		return ""
+	}
+	s, err := v.String()
+	r.check(keys, err)
+	return s
+}
+
+// Int returns int value by the given keys path.
+func (r *Reader) Int(keys ...string) int {
+	v := r.Value(keys...)
+	if v == nil {
+		return 0
+	}
+	n, err := v.Int()
+	r.check(keys, err)
+	return n
+}
+
+// Int64 returns int64 value by the given keys path.
+func (r *Reader) Int64(keys ...string) int64 {
+	v := r.Value(keys...)
+	if v == nil {
+		return 0
+	}
+	n, err := v.Int64()
+	r.check(keys, err)
+	return n
+}
+
+// Uint64 returns uint64 value by the given keys path.
+func (r *Reader) Uint64(keys ...string) uint64 {
+	v := r.Value(keys...)
+	if v == nil {
+		return 0
+	}
+	n, err := v.Uint64()
+	r.check(keys, err)
+	return n
+}
+
+// Float64 returns float64 value by the given keys path.
+func (r *Reader) Float64(keys ...string) float64 {
+	v := r.Value(keys...)
+	if v == nil {
+		return 0
+	}
+	f, err := v.Float64()
+	r.check(keys, err)
+	return f
+}
+
+// Bool returns bool value by the given keys path.
+func (r *Reader) Bool(keys ...string) bool {
+	v := r.Value(keys...)
+	if v == nil {
+		return false
+	}
+	b, err := v.Bool()
+	r.check(keys, err)
+	return b
+}
+
+// StringOr returns string value by the given keys path.
+//
+// def is returned without recording an error for non-existing keys path.
+func (r *Reader) StringOr(def string, keys ...string) string {
+	if !r.v.Exists(keys...) {
+		return def
+	}
+	return r.String(keys...)
+}
+
+// IntOr returns int value by the given keys path.
+//
+// def is returned without recording an error for non-existing keys path.
+func (r *Reader) IntOr(def int, keys ...string) int {
+	if !r.v.Exists(keys...) {
+		return def
+	}
+	return r.Int(keys...)
+}
+
+// Int64Or returns int64 value by the given keys path.
+//
+// def is returned without recording an error for non-existing keys path.
+func (r *Reader) Int64Or(def int64, keys ...string) int64 {
+	if !r.v.Exists(keys...) {
+		return def
+	}
+	return r.Int64(keys...)
+}
+
+// Float64Or returns float64 value by the given keys path.
+//
+// def is returned without recording an error for non-existing keys path.
+func (r *Reader) Float64Or(def float64, keys ...string) float64 {
+	if !r.v.Exists(keys...) {
+		return def
+	}
+	return r.Float64(keys...)
+}
+
+// BoolOr returns bool value by the given keys path.
+//
+// def is returned without recording an error for non-existing keys path.
+func (r *Reader) BoolOr(def bool, keys ...string) bool {
+	if !r.v.Exists(keys...) {
+		return def
+	}
+	return r.Bool(keys...)
+}
+
+func (r *Reader) check(keys []string, err error) {
+	if err != nil {
+		r.record(keys, err)
+	}
+}
+
+func (r *Reader) record(keys []string, err error) {
+	r.errs = append(r.errs, &PathError{
+		Path: append([]string(nil), keys...),
+		Err:  err,
+	})
+}
@@ -0,0 +1,51 @@
+package jsonpart
+
+import (
+	"fmt"
+	"strings"
+)
+
+// replaceMarshalOptions are used for serializing replacements,
+// so they may be safely embedded into HTML and <script> contents.
+var replaceMarshalOptions = MarshalOptions{
+	EscapeHTML:            true,
+	EscapeLineTerminators: true,
+}
+
+// Replace replaces the JSON value for partialKey embedded in s with the value
+// returned by f and returns the resulting document.
+//
+// f receives the parsed value and may modify it in place or return a new one.
+// Only the text of the value is replaced, while the rest of s, including
+// whitespace around the value, remains unchanged. The whole s is replaced
+// if partialKey is empty.
+//
+// The replacement is serialized compactly with <, >, &, U+2028 and U+2029
+// escaped in strings, so it cannot break out of <script> tags or JavaScript
+// string context.
+//
+// s is returned unchanged together with the error if parsing fails or f returns an error.
+func Replace(s, partialKey string, f func(v *Value) (*Value, error)) (string, error) {
+	v, err := Parse(s, partialKey)
+	if err != nil {
+		return s, err
+	}
+	start, end := v.Offset()
+	if start < 0 {
+		panic(fmt.Errorf("BUG: parsed value has no source span"))
+	}
+	nv, err := f(v)
+	if err != nil {
+		return s, err
+	}
+	if nv == nil {
+		return s, fmt.Errorf("cannot replace value for partialKey %q with nil value", partialKey)
+	}
+	data := nv.MarshalWith(replaceMarshalOptions)
+	var b strings.Builder
+	b.Grow(len(s) - (end - start) + len(data))
+	b.WriteString(s[:start])
+	b.Write(data)
+	b.WriteString(s[end:])
+	return b.String(), nil
+}
@@ -0,0 +1,538 @@
+// Package schema validates jsonpart values against JSON Schema.
+//
+// The core and validation vocabularies of JSON Schema draft 2020-12
+// are supported with the following limitations:
+//
+//   - $ref may refer only to the schema document itself, to its subschemas
+//     identified by $id and to $anchor names. Remote references aren't supported.
+//   - unevaluatedItems, unevaluatedProperties, $dynamicRef and format are ignored.
+//   - pattern and patternProperties use Go regexp syntax (RE2).
+//
+// The array form of items together with additionalItems from older drafts
+// is supported as well.
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// Schema is a compiled JSON Schema.
+//
+// Schema may be used from concurrent goroutines if the validated values
+// are frozen or aren't shared between goroutines. See jsonpart.Value.Freeze.
+type Schema struct {
+	root *node
+}
+
+// node is a compiled schema or subschema.
+type node struct {
+	// loc is JSON pointer to the node in the schema document.
+	loc string
+
+	// always is non-nil for boolean schemas.
+	always *bool
+
+	ref    string
+	refDst *node
+
+	types    []string
+	enum     []*jsonpart.Value
+	constant *jsonpart.Value
+
+	multipleOf       *jsonpart.Value
+	maximum          *float64
+	exclusiveMaximum *float64
+	minimum          *float64
+	exclusiveMinimum *float64
+
+	maxLength int
+	minLength int
+	pattern   *regexp.Regexp
+
+	maxItems    int
+	minItems    int
+	uniqueItems bool
+	maxContains int
+	minContains int
+
+	maxProperties     int
+	minProperties     int
+	required          []string
+	dependentRequired []dependentRequired
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ifNode   *node
+	thenNode *node
+	elseNode *node
+
+	properties           map[string]*node
+	patternProperties    []patternNode
+	additionalProperties *node
+	propertyNames        *node
+	dependentSchemas     []dependentSchema
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+}
+
+type patternNode struct {
+	re *regexp.Regexp
+	n  *node
+}
+
+// dependentRequired lists properties required when key is present.
+type dependentRequired struct {
+	key  string
+	deps []string
+}
+
+// dependentSchema is the schema applied when key is present.
+type dependentSchema struct {
+	key string
+	n   *node
+}
+
+// Compile compiles JSON Schema from v.
+//
+// The returned Schema doesn't reference v.
+func Compile(v *jsonpart.Value) (*Schema, error) {
+	if v == nil {
+		return nil, fmt.Errorf("schema cannot be nil")
+	}
+	// The cloned document is frozen, so values referenced by the compiled
+	// schema, such as enum items, may be read from concurrent goroutines.
+	doc := v.Clone()
+	doc.Freeze()
+	c := &compiler{
+		doc:     doc,
+		nodes:   make(map[string]*node),
+		ids:     make(map[string]string),
+		anchors: make(map[string]string),
+	}
+	if err := c.collectIDs(c.doc, nil); err != nil {
+		return nil, err
+	}
+	root, err := c.compile(c.doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	for len(c.pending) > 0 {
+		n := c.pending[0]
+		c.pending = c.pending[1:]
+		if n.refDst, err = c.resolve(n.ref); err != nil {
+			return nil, fmt.Errorf("cannot resolve $ref %q at %q: %s", n.ref, n.loc, err)
+		}
+	}
+	return &Schema{root: root}, nil
+}
+
+// compiler holds the state for Compile.
+type compiler struct {
+	doc *jsonpart.Value
+
+	// nodes contains compiled nodes by their locations.
+	nodes map[string]*node
+
+	// ids and anchors map $id and $anchor values to the locations of their schemas.
+	ids     map[string]string
+	anchors map[string]string
+
+	// pending contains nodes with unresolved $ref.
+	pending []*node
+}
+
+// collectIDs fills c.ids and c.anchors, so $ref may refer to schemas defined later in the document.
+func (c *compiler) collectIDs(v *jsonpart.Value, path []string) error {
+	switch v.Type() {
+	case jsonpart.TypeObject:
+		loc := jsonpart.FormatPointer(path)
+		if id := v.Get("$id"); id != nil {
+			s, err := id.String()
+			if err != nil {
+				return fmt.Errorf("invalid $id at %q: %s", loc, err)
+			}
+			c.ids[strings.TrimSuffix(s, "#")] = loc
+		}
+		if anchor := v.Get("$anchor"); anchor != nil {
+			s, err := anchor.String()
+			if err != nil {
+				return fmt.Errorf("invalid $anchor at %q: %s", loc, err)
+			}
+			c.anchors[s] = loc
+		}
+		var err error
+		v.GetObject().Visit(func(key []byte, vv *jsonpart.Value) {
+			if err == nil {
+				err = c.collectIDs(vv, append(path, string(key)))
+			}
+		})
+		return err
+	case jsonpart.TypeArray:
+		for i, vv := range v.GetArray() {
+			if err := c.collectIDs(vv, append(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *compiler) resolve(ref string) (*node, error) {
+	base, fragment := ref, ""
+	if n := strings.IndexByte(ref, '#'); n >= 0 {
+		base, fragment = ref[:n], ref[n+1:]
+	}
+	var loc string
+	if base != "" {
+		l, ok := c.ids[base]
+		if !ok {
+			return nil, fmt.Errorf("unknown schema %q; remote references aren't supported", base)
+		}
+		loc = l
+	}
+	if fragment != "" && fragment[0] != '/' {
+		l, ok := c.anchors[fragment]
+		if !ok {
+			return nil, fmt.Errorf("unknown anchor %q", fragment)
+		}
+		loc, fragment = l, ""
+	}
+	path, err := jsonpart.ParsePointer(loc + unescapeURIFragment(fragment))
+	if err != nil {
+		return nil, err
+	}
+	v := c.doc.Get(path...)
+	if v == nil {
+		return nil, fmt.Errorf("cannot find schema at %q", jsonpart.FormatPointer(path))
+	}
+	return c.compile(v, path)
+}
+
+// unescapeURIFragment unescapes %XX sequences in the URI fragment s.
+func unescapeURIFragment(s string) string {
+	if strings.IndexByte(s, '%') < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if x, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(x))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func (c *compiler) compile(v *jsonpart.Value, path []string) (*node, error) {
+	loc := jsonpart.FormatPointer(path)
+	if n, ok := c.nodes[loc]; ok {
+		return n, nil
+	}
+	n := &node{
+		loc:           loc,
+		maxLength:     -1,
+		minLength:     -1,
+		maxItems:      -1,
+		minItems:      -1,
+		maxContains:   -1,
+		minContains:   -1,
+		maxProperties: -1,
+		minProperties: -1,
+	}
+	c.nodes[loc] = n
+
+	switch v.Type() {
+	case jsonpart.TypeTrue, jsonpart.TypeFalse:
+		b := v.Type() == jsonpart.TypeTrue
+		n.always = &b
+		return n, nil
+	case jsonpart.TypeObject:
+	default:
+		return nil, fmt.Errorf("schema at %q must be object or boolean; it contains %s", loc, v.Type())
+	}
+
+	kc := keywordCompiler{c: c, v: v, path: path}
+	kc.compileKeywords(n)
+	if kc.err != nil {
+		return nil, kc.err
+	}
+	return n, nil
+}
+
+// keywordCompiler compiles keywords of a single schema object.
+//
+// The first error is stored in err, so the keywords may be compiled
+// without checking errors after every keyword.
+type keywordCompiler struct {
+	c    *compiler
+	v    *jsonpart.Value
+	path []string
+	err  error
+}
+
+func (kc *keywordCompiler) fail(keyword string, format string, args ...any) {
+	if kc.err == nil {
+		loc := jsonpart.FormatPointer(append(kc.path, keyword))
+		kc.err = fmt.Errorf("invalid %s at %q: %s", keyword, loc, fmt.Sprintf(format, args...))
+	}
+}
+
+func (kc *keywordCompiler) schema(keyword string, keys ...string) *node {
+	v := kc.v.Get(append([]string{keyword}, keys...)...)
+	if v == nil || kc.err != nil {
+		return nil
+	}
+	path := append(append(append([]string(nil), kc.path...), keyword), keys...)
+	n, err := kc.c.compile(v, path)
+	if err != nil {
+		kc.err = err
+		return nil
+	}
+	return n
+}
+
+func (kc *keywordCompiler) schemaList(keyword string) []*node {
+	v := kc.v.Get(keyword)
+	if v == nil {
+		return nil
+	}
+	a, err := v.Array()
+	if err != nil {
+		kc.fail(keyword, "%s", err)
+		return nil
+	}
+	ns := make([]*node, 0, len(a))
+	for i := range a {
+		ns = append(ns, kc.schema(keyword, strconv.Itoa(i)))
+	}
+	return ns
+}
+
+func (kc *keywordCompiler) schemaMap(keyword string) map[string]*node {
+	o := kc.object(keyword)
+	if o == nil {
+		return nil
+	}
+	m := make(map[string]*node, o.Len())
+	o.Visit(func(key []byte, _ *jsonpart.Value) {
+		k := string(key)
+		m[k] = kc.schema(keyword, k)
+	})
+	return m
+}
+
+func (kc *keywordCompiler) object(keyword string) *jsonpart.Object {
+	v := kc.v.Get(keyword)
+	if v == nil {
+		return nil
+	}
+	o, err := v.Object()
+	if err != nil {
+		kc.fail(keyword, "%s", err)
+		return nil
+	}
+	return o
+}
+
+func (kc *keywordCompiler) number(keyword string) *float64 {
+	v := kc.v.Get(keyword)
+	if v == nil {
+		return nil
+	}
+	f, err := v.Float64()
+	if err != nil {
+		kc.fail(keyword, "%s", err)
+		return nil
+	}
+	return &f
+}
+
+func (kc *keywordCompiler) count(keyword string) int {
+	v := kc.v.Get(keyword)
+	if v == nil {
+		return -1
+	}
+	f, err := v.Float64()
+	if err != nil || f < 0 || f != float64(int(f)) {
+		kc.fail(keyword, "must be non-negative integer; got %s", v.MarshalString())
+		return -1
+	}
+	return int(f)
+}
+
+func (kc *keywordCompiler) strings(keyword string, v *jsonpart.Value) []string {
+	a, err := v.Array()
+	if err != nil {
+		kc.fail(keyword, "%s", err)
+		return nil
+	}
+	ss := make([]string, 0, len(a))
+	for _, item := range a {
+		s, err := item.String()
+		if err != nil {
+			kc.fail(keyword, "%s", err)
+			return nil
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+func (kc *keywordCompiler) regexp(keyword, expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		kc.fail(keyword, "%s", err)
+		return nil
+	}
+	return re
+}
+
+var knownTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"number":  true,
+	"string":  true,
+	"integer": true,
+}
+
+func (kc *keywordCompiler) compileKeywords(n *node) {
+	v := kc.v
+
+	if ref := v.Get("$ref"); ref != nil {
+		s, err := ref.String()
+		if err != nil {
+			kc.fail("$ref", "%s", err)
+		}
+		n.ref = s
+		kc.c.pending = append(kc.c.pending, n)
+	}
+
+	// Validation keywords.
+	if t := v.Get("type"); t != nil {
+		if t.Type() == jsonpart.TypeString {
+			n.types = []string{t.GetString()}
+		} else {
+			n.types = kc.strings("type", t)
+		}
+		for _, s := range n.types {
+			if !knownTypes[s] {
+				kc.fail("type", "unknown type %q", s)
+			}
+		}
+	}
+	if e := v.Get("enum"); e != nil {
+		a, err := e.Array()
+		if err != nil {
+			kc.fail("enum", "%s", err)
+		}
+		n.enum = a
+	}
+	n.constant = v.Get("const")
+	if m := v.Get("multipleOf"); m != nil {
+		if f, err := m.Float64(); err != nil || f <= 0 {
+			kc.fail("multipleOf", "must be positive number; got %s", m.MarshalString())
+		}
+		n.multipleOf = m
+	}
+	n.maximum = kc.number("maximum")
+	n.exclusiveMaximum = kc.number("exclusiveMaximum")
+	n.minimum = kc.number("minimum")
+	n.exclusiveMinimum = kc.number("exclusiveMinimum")
+	n.maxLength = kc.count("maxLength")
+	n.minLength = kc.count("minLength")
+	if p := v.Get("pattern"); p != nil {
+		s, err := p.String()
+		if err != nil {
+			kc.fail("pattern", "%s", err)
+		}
+		n.pattern = kc.regexp("pattern", s)
+	}
+	n.maxItems = kc.count("maxItems")
+	n.minItems = kc.count("minItems")
+	n.uniqueItems = v.GetBool("uniqueItems")
+	n.maxContains = kc.count("maxContains")
+	n.minContains = kc.count("minContains")
+	n.maxProperties = kc.count("maxProperties")
+	n.minProperties = kc.count("minProperties")
+	if r := v.Get("required"); r != nil {
+		n.required = kc.strings("required", r)
+	}
+	// Dependencies are sorted by key like patternProperties,
+	// so validation errors are reported in stable order.
+	if o := kc.object("dependentRequired"); o != nil {
+		o.Visit(func(key []byte, vv *jsonpart.Value) {
+			n.dependentRequired = append(n.dependentRequired, dependentRequired{
+				key:  string(key),
+				deps: kc.strings("dependentRequired", vv),
+			})
+		})
+		sort.SliceStable(n.dependentRequired, func(i, j int) bool {
+			return n.dependentRequired[i].key < n.dependentRequired[j].key
+		})
+	}
+
+	// Applicator keywords.
+	n.allOf = kc.schemaList("allOf")
+	n.anyOf = kc.schemaList("anyOf")
+	n.oneOf = kc.schemaList("oneOf")
+	n.not = kc.schema("not")
+	n.ifNode = kc.schema("if")
+	n.thenNode = kc.schema("then")
+	n.elseNode = kc.schema("else")
+
+	n.properties = kc.schemaMap("properties")
+	if o := kc.object("patternProperties"); o != nil {
+		var keys []string
+		o.Visit(func(key []byte, _ *jsonpart.Value) {
+			keys = append(keys, string(key))
+		})
+		sort.Strings(keys)
+		for _, k := range keys {
+			n.patternProperties = append(n.patternProperties, patternNode{
+				re: kc.regexp("patternProperties", k),
+				n:  kc.schema("patternProperties", k),
+			})
+		}
+	}
+	n.additionalProperties = kc.schema("additionalProperties")
+	n.propertyNames = kc.schema("propertyNames")
+	if o := kc.object("dependentSchemas"); o != nil {
+		o.Visit(func(key []byte, _ *jsonpart.Value) {
+			k := string(key)
+			n.dependentSchemas = append(n.dependentSchemas, dependentSchema{
+				key: k,
+				n:   kc.schema("dependentSchemas", k),
+			})
+		})
+		sort.SliceStable(n.dependentSchemas, func(i, j int) bool {
+			return n.dependentSchemas[i].key < n.dependentSchemas[j].key
+		})
+	}
+
+	n.prefixItems = kc.schemaList("prefixItems")
+	if items := v.Get("items"); items != nil && items.Type() == jsonpart.TypeArray {
+		// The array form of items from older drafts.
+		n.prefixItems = kc.schemaList("items")
+		n.items = kc.schema("additionalItems")
+	} else {
+		n.items = kc.schema("items")
+	}
+	n.contains = kc.schema("contains")
+}
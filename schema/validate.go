@@ -0,0 +1,395 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeffreyzj/jsonpart"
+)
+
+// ValidationError describes a single schema violation.
+type ValidationError struct {
+	// InstanceLocation is JSON pointer to the invalid value.
+	InstanceLocation string
+
+	// KeywordLocation is JSON pointer to the violated keyword in the schema.
+	KeywordLocation string
+
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements error interface.
+func (e *ValidationError) Error() string {
+	loc := e.InstanceLocation
+	if loc == "" {
+		loc = "/"
+	}
+	return fmt.Sprintf("%s: %s (schema %q)", loc, e.Message, e.KeywordLocation)
+}
+
+// maxValidateDepth limits the nesting of schema applications,
+// so recursive $ref cannot result in endless validation.
+const maxValidateDepth = 1000
+
+// Validate validates v against s and returns all the found violations.
+//
+// nil is returned if v is valid.
+func (s *Schema) Validate(v *jsonpart.Value) []*ValidationError {
+	if v == nil {
+		return []*ValidationError{{
+			KeywordLocation: s.root.loc,
+			Message:         "missing value",
+		}}
+	}
+	var vd validator
+	vd.validate(s.root, v, nil, 0)
+	return vd.errs
+}
+
+// Valid returns true if v is valid against s.
+func (s *Schema) Valid(v *jsonpart.Value) bool {
+	if v == nil {
+		return false
+	}
+	vd := validator{
+		stopOnError: true,
+	}
+	vd.validate(s.root, v, nil, 0)
+	return len(vd.errs) == 0
+}
+
+type validator struct {
+	errs        []*ValidationError
+	stopOnError bool
+}
+
+func (vd *validator) addError(n *node, keyword string, path []string, format string, args ...any) {
+	kl := n.loc
+	if keyword != "" {
+		kl += "/" + keyword
+	}
+	vd.errs = append(vd.errs, &ValidationError{
+		InstanceLocation: jsonpart.FormatPointer(path),
+		KeywordLocation:  kl,
+		Message:          fmt.Sprintf(format, args...),
+	})
+}
+
+// matches returns true if v is valid against n without recording errors.
+func (vd *validator) matches(n *node, v *jsonpart.Value, path []string, depth int) bool {
+	sub := validator{
+		stopOnError: true,
+	}
+	sub.validate(n, v, path, depth)
+	return len(sub.errs) == 0
+}
+
+var equalOptions = jsonpart.EqualOptions{
+	IgnoreKeyOrder: true,
+	NumericNumbers: true,
+}
+
+func (vd *validator) validate(n *node, v *jsonpart.Value, path []string, depth int) {
+	if vd.stopOnError && len(vd.errs) > 0 {
+		return
+	}
+	if depth > maxValidateDepth {
+		vd.addError(n, "", path, "too deep schema nesting; it exceeds %d; probably $ref loop", maxValidateDepth)
+		return
+	}
+	depth++
+
+	if n.always != nil {
+		if !*n.always {
+			vd.addError(n, "", path, "no value is allowed")
+		}
+		return
+	}
+	if n.refDst != nil {
+		vd.validate(n.refDst, v, path, depth)
+	}
+
+	t := v.Type()
+	if len(n.types) > 0 && !typeMatches(n.types, v) {
+		vd.addError(n, "type", path, "expected %s; got %s", strings.Join(n.types, " or "), typeName(v))
+	}
+	if n.enum != nil {
+		found := false
+		for _, e := range n.enum {
+			if jsonpart.EqualWith(v, e, equalOptions) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vd.addError(n, "enum", path, "value %s isn't one of the allowed values", shorten(v))
+		}
+	}
+	if n.constant != nil && !jsonpart.EqualWith(v, n.constant, equalOptions) {
+		vd.addError(n, "const", path, "value %s must be equal to %s", shorten(v), shorten(n.constant))
+	}
+
+	switch t {
+	case jsonpart.TypeNumber:
+		vd.validateNumber(n, v, path)
+	case jsonpart.TypeString:
+		vd.validateString(n, v, path)
+	case jsonpart.TypeArray:
+		vd.validateArray(n, v, path, depth)
+	case jsonpart.TypeObject:
+		vd.validateObject(n, v, path, depth)
+	}
+
+	for _, sub := range n.allOf {
+		vd.validate(sub, v, path, depth)
+	}
+	if len(n.anyOf) > 0 {
+		found := false
+		for _, sub := range n.anyOf {
+			if vd.matches(sub, v, path, depth) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vd.addError(n, "anyOf", path, "value doesn't match any schema")
+		}
+	}
+	if len(n.oneOf) > 0 {
+		matched := 0
+		for _, sub := range n.oneOf {
+			if vd.matches(sub, v, path, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			vd.addError(n, "oneOf", path, "value must match exactly one schema; it matches %d schemas", matched)
+		}
+	}
+	if n.not != nil && vd.matches(n.not, v, path, depth) {
+		vd.addError(n, "not", path, "value must not match the schema")
+	}
+	if n.ifNode != nil {
+		if vd.matches(n.ifNode, v, path, depth) {
+			if n.thenNode != nil {
+				vd.validate(n.thenNode, v, path, depth)
+			}
+		} else if n.elseNode != nil {
+			vd.validate(n.elseNode, v, path, depth)
+		}
+	}
+}
+
+func typeMatches(types []string, v *jsonpart.Value) bool {
+	name := typeName(v)
+	for _, t := range types {
+		if t == name || (t == "number" && name == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns JSON Schema type name for v.
+func typeName(v *jsonpart.Value) string {
+	switch v.Type() {
+	case jsonpart.TypeNull:
+		return "null"
+	case jsonpart.TypeTrue, jsonpart.TypeFalse:
+		return "boolean"
+	case jsonpart.TypeObject:
+		return "object"
+	case jsonpart.TypeArray:
+		return "array"
+	case jsonpart.TypeString:
+		return "string"
+	default:
+		f, err := v.Float64()
+		if err == nil && !math.IsInf(f, 0) && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+}
+
+func shorten(v *jsonpart.Value) string {
+	s := v.MarshalString()
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
+
+func (vd *validator) validateNumber(n *node, v *jsonpart.Value, path []string) {
+	f, err := v.Float64()
+	if err != nil {
+		vd.addError(n, "type", path, "invalid number %s: %s", shorten(v), err)
+		return
+	}
+	if n.maximum != nil && f > *n.maximum {
+		vd.addError(n, "maximum", path, "value %s must be <= %v", shorten(v), *n.maximum)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		vd.addError(n, "exclusiveMaximum", path, "value %s must be < %v", shorten(v), *n.exclusiveMaximum)
+	}
+	if n.minimum != nil && f < *n.minimum {
+		vd.addError(n, "minimum", path, "value %s must be >= %v", shorten(v), *n.minimum)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		vd.addError(n, "exclusiveMinimum", path, "value %s must be > %v", shorten(v), *n.exclusiveMinimum)
+	}
+	if n.multipleOf != nil && !isMultipleOf(v.MarshalString(), n.multipleOf.MarshalString()) {
+		vd.addError(n, "multipleOf", path, "value %s must be a multiple of %s", shorten(v), n.multipleOf.MarshalString())
+	}
+}
+
+// isMultipleOf returns true if number x is a multiple of number m.
+//
+// Exact decimal arithmetic is used, so 0.3 is a multiple of 0.1.
+func isMultipleOf(x, m string) bool {
+	rx, okx := parseRat(x)
+	rm, okm := parseRat(m)
+	if !okx || !okm {
+		// Fall back to float64 for numbers with too big exponents.
+		fx, _ := strconv.ParseFloat(x, 64)
+		fm, _ := strconv.ParseFloat(m, 64)
+		q := fx / fm
+		return !math.IsInf(q, 0) && q == math.Trunc(q)
+	}
+	return new(big.Rat).Quo(rx, rm).IsInt()
+}
+
+// maxRatExponent limits exponents for exact arithmetic, since big.Rat
+// allocates memory proportional to the exponent.
+const maxRatExponent = 1000
+
+func parseRat(s string) (*big.Rat, bool) {
+	if n := strings.IndexAny(s, "eE"); n >= 0 {
+		exp, err := strconv.Atoi(strings.TrimPrefix(s[n+1:], "+"))
+		if err != nil || exp > maxRatExponent || exp < -maxRatExponent {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(s)
+}
+
+func (vd *validator) validateString(n *node, v *jsonpart.Value, path []string) {
+	s := v.GetString()
+	if n.maxLength >= 0 || n.minLength >= 0 {
+		length := utf8.RuneCountInString(s)
+		if n.maxLength >= 0 && length > n.maxLength {
+			vd.addError(n, "maxLength", path, "string length %d exceeds %d", length, n.maxLength)
+		}
+		if n.minLength >= 0 && length < n.minLength {
+			vd.addError(n, "minLength", path, "string length %d is less than %d", length, n.minLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		vd.addError(n, "pattern", path, "string %s doesn't match pattern %q", shorten(v), n.pattern.String())
+	}
+}
+
+func (vd *validator) validateArray(n *node, v *jsonpart.Value, path []string, depth int) {
+	a := v.GetArray()
+	if n.maxItems >= 0 && len(a) > n.maxItems {
+		vd.addError(n, "maxItems", path, "array length %d exceeds %d", len(a), n.maxItems)
+	}
+	if n.minItems >= 0 && len(a) < n.minItems {
+		vd.addError(n, "minItems", path, "array length %d is less than %d", len(a), n.minItems)
+	}
+	if n.uniqueItems {
+	outer:
+		for i := range a {
+			for j := i + 1; j < len(a); j++ {
+				if jsonpart.EqualWith(a[i], a[j], equalOptions) {
+					vd.addError(n, "uniqueItems", path, "array items #%d and #%d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+	for i, item := range a {
+		itemPath := append(path, strconv.Itoa(i))
+		if i < len(n.prefixItems) {
+			vd.validate(n.prefixItems[i], item, itemPath, depth)
+		} else if n.items != nil {
+			vd.validate(n.items, item, itemPath, depth)
+		}
+	}
+	if n.contains != nil {
+		matched := 0
+		for i, item := range a {
+			if vd.matches(n.contains, item, append(path, strconv.Itoa(i)), depth) {
+				matched++
+			}
+		}
+		minContains := 1
+		if n.minContains >= 0 {
+			minContains = n.minContains
+		}
+		if matched < minContains {
+			vd.addError(n, "contains", path, "array must contain at least %d matching items; it contains %d", minContains, matched)
+		}
+		if n.maxContains >= 0 && matched > n.maxContains {
+			vd.addError(n, "maxContains", path, "array must contain at most %d matching items; it contains %d", n.maxContains, matched)
+		}
+	}
+}
+
+func (vd *validator) validateObject(n *node, v *jsonpart.Value, path []string, depth int) {
+	o := v.GetObject()
+	if n.maxProperties >= 0 && o.Len() > n.maxProperties {
+		vd.addError(n, "maxProperties", path, "object has %d properties; it may have at most %d", o.Len(), n.maxProperties)
+	}
+	if n.minProperties >= 0 && o.Len() < n.minProperties {
+		vd.addError(n, "minProperties", path, "object has %d properties; it must have at least %d", o.Len(), n.minProperties)
+	}
+	for _, key := range n.required {
+		if o.Get(key) == nil {
+			vd.addError(n, "required", path, "missing required property %q", key)
+		}
+	}
+	for _, dr := range n.dependentRequired {
+		if o.Get(dr.key) == nil {
+			continue
+		}
+		for _, dep := range dr.deps {
+			if o.Get(dep) == nil {
+				vd.addError(n, "dependentRequired", path, "property %q is required when %q is present", dep, dr.key)
+			}
+		}
+	}
+	for _, ds := range n.dependentSchemas {
+		if o.Get(ds.key) != nil {
+			vd.validate(ds.n, v, path, depth)
+		}
+	}
+
+	var ar jsonpart.Arena
+	o.Visit(func(keyB []byte, item *jsonpart.Value) {
+		key := string(keyB)
+		itemPath := append(path, key)
+		evaluated := false
+		if sub, ok := n.properties[key]; ok {
+			vd.validate(sub, item, itemPath, depth)
+			evaluated = true
+		}
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(key) {
+				vd.validate(pp.n, item, itemPath, depth)
+				evaluated = true
+			}
+		}
+		if !evaluated && n.additionalProperties != nil {
+			vd.validate(n.additionalProperties, item, itemPath, depth)
+		}
+		if n.propertyNames != nil && !vd.matches(n.propertyNames, ar.NewString(key), itemPath, depth) {
+			vd.addError(n, "propertyNames", path, "invalid property name %q", key)
+		}
+	})
+}
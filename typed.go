@@ -0,0 +1,333 @@
+package jsonpart
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Time returns the underlying JSON string for the v parsed with the given layouts.
+//
+// Layouts are tried in order until the first successful parse.
+// time.RFC3339Nano is used if no layouts are given.
+//
+// Use GetTime if you don't need error handling.
+func (v *Value) Time(layouts ...string) (time.Time, error) {
+	s, err := v.String()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	var firstErr error
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time from %q: %w", startEndString(s), firstErr)
+}
+
+// UnixTime returns the time for the v containing Unix time in seconds.
+//
+// The v may be a number or a string containing a number.
+// Fractional seconds are supported with nanosecond precision.
+//
+// Use GetUnixTime if you don't need error handling.
+func (v *Value) UnixTime() (time.Time, error) {
+	return v.unixTime(time.Second)
+}
+
+// UnixMilli returns the time for the v containing Unix time in milliseconds.
+//
+// The v may be a number or a string containing a number.
+//
+// Use GetUnixMilli if you don't need error handling.
+func (v *Value) UnixMilli() (time.Time, error) {
+	return v.unixTime(time.Millisecond)
+}
+
+func (v *Value) unixTime(unit time.Duration) (time.Time, error) {
+	ns, err := v.nanoseconds(unit)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, nsec := new(big.Int).DivMod(ns, big.NewInt(int64(time.Second)), new(big.Int))
+	if !sec.IsInt64() {
+		return time.Time{}, fmt.Errorf("unix time %s is out of range", v.numberOrString())
+	}
+	return time.Unix(sec.Int64(), nsec.Int64()), nil
+}
+
+// Duration returns the duration for the v.
+//
+// The v may contain a string in time.ParseDuration format such as "1h5m",
+// ISO 8601 duration such as "PT5M" or "P1DT2H", or a number of seconds.
+// ISO 8601 durations with years or months are rejected, since they
+// have no fixed length.
+//
+// Use GetDuration if you don't need error handling.
+func (v *Value) Duration() (time.Duration, error) {
+	if v.vType() == TypeString {
+		s := v.s
+		if strings.HasPrefix(s, "P") || strings.HasPrefix(s, "-P") {
+			return parseISODuration(s)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse duration: %w", err)
+		}
+		return d, nil
+	}
+	ns, err := v.nanoseconds(time.Second)
+	if err != nil {
+		return 0, err
+	}
+	if !ns.IsInt64() {
+		return 0, fmt.Errorf("duration %s seconds is out of range", v.s)
+	}
+	return time.Duration(ns.Int64()), nil
+}
+
+// nanoseconds returns the number of nanoseconds in the v containing
+// the number of the given units.
+//
+// Fractional nanoseconds are truncated towards negative infinity.
+func (v *Value) nanoseconds(unit time.Duration) (*big.Int, error) {
+	if v.vType() != TypeNumber && v.vType() != TypeString {
+		return nil, fmt.Errorf("value doesn't contain number or string; it contains %s", v.vType())
+	}
+	s := v.s
+	if !isJSONNumber(s) {
+		return nil, fmt.Errorf("cannot parse number from %q", startEndString(s))
+	}
+	r, err := parseBigRat(s)
+	if err != nil {
+		return nil, err
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(unit)))
+	// big.Int.Div uses Euclidean division, so negative times are floored.
+	return new(big.Int).Div(r.Num(), r.Denom()), nil
+}
+
+// numberOrString returns the text of number or string v.
+func (v *Value) numberOrString() string {
+	switch v.vType() {
+	case TypeNumber, TypeString:
+		return v.s
+	default:
+		return ""
+	}
+}
+
+// isoDurationUnits contains ISO 8601 duration designators in the required order.
+var isoDurationUnits = []struct {
+	designator byte
+	timePart   bool
+	d          time.Duration
+}{
+	{'Y', false, 0},
+	{'M', false, 0},
+	{'W', false, 7 * 24 * time.Hour},
+	{'D', false, 24 * time.Hour},
+	{'H', true, time.Hour},
+	{'M', true, time.Minute},
+	{'S', true, time.Second},
+}
+
+// parseISODuration parses ISO 8601 duration such as "P1DT2H30M" or "PT0.5S".
+func parseISODuration(s string) (time.Duration, error) {
+	orig := s
+	minus := strings.HasPrefix(s, "-")
+	if minus {
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "P")
+	if s == "" || s == "T" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("cannot parse ISO 8601 duration %q", orig)
+	}
+	total := new(big.Rat)
+	timePart := false
+	next := 0
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			if timePart {
+				return 0, fmt.Errorf("cannot parse ISO 8601 duration %q: duplicate 'T'", orig)
+			}
+			timePart = true
+			s = s[1:]
+			continue
+		}
+		n := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != ','
+		})
+		if n <= 0 {
+			return 0, fmt.Errorf("cannot parse ISO 8601 duration %q: missing number or designator", orig)
+		}
+		num, ok := new(big.Rat).SetString(strings.Replace(s[:n], ",", ".", 1))
+		if !ok || len(s[:n]) > 30 {
+			return 0, fmt.Errorf("cannot parse ISO 8601 duration %q: invalid number %q", orig, s[:n])
+		}
+		designator := s[n]
+		s = s[n+1:]
+		i := next
+		for i < len(isoDurationUnits) && (isoDurationUnits[i].designator != designator || isoDurationUnits[i].timePart != timePart) {
+			i++
+		}
+		if i == len(isoDurationUnits) {
+			return 0, fmt.Errorf("cannot parse ISO 8601 duration %q: unexpected designator %q", orig, designator)
+		}
+		u := isoDurationUnits[i]
+		if u.d == 0 {
+			return 0, fmt.Errorf("cannot parse ISO 8601 duration %q: years and months have no fixed duration", orig)
+		}
+		next = i + 1
+		total.Add(total, num.Mul(num, new(big.Rat).SetInt64(int64(u.d))))
+	}
+	ns := new(big.Int).Quo(total.Num(), total.Denom())
+	if minus {
+		ns.Neg(ns)
+	}
+	if !ns.IsInt64() {
+		return 0, fmt.Errorf("ISO 8601 duration %q is out of range", orig)
+	}
+	return time.Duration(ns.Int64()), nil
+}
+
+// Base64 returns the decoded base64 payload of the underlying JSON string for the v.
+//
+// Standard and URL-safe alphabets are accepted, with or without padding.
+//
+// Use GetBase64 if you don't need error handling.
+func (v *Value) Base64() ([]byte, error) {
+	s, err := v.String()
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode base64 from %q: %w", startEndString(s), err)
+	}
+	return b, nil
+}
+
+// URL returns the URL parsed from the underlying JSON string for the v.
+//
+// Use GetURL if you don't need error handling.
+func (v *Value) URL() (*url.URL, error) {
+	s, err := v.String()
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return nil, fmt.Errorf("cannot parse URL from empty string")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse URL: %w", err)
+	}
+	return u, nil
+}
+
+// GetTime returns time parsed with the given layouts by the given keys path.
+//
+// time.RFC3339Nano is used if layouts is empty.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// Zero time is returned for non-existing keys path or for invalid value.
+func (v *Value) GetTime(layouts []string, keys ...string) time.Time {
+	r := v.Get(keys...)
+	if r == nil {
+		return time.Time{}
+	}
+	t, _ := r.Time(layouts...)
+	return t
+}
+
+// GetUnixTime returns time from Unix seconds by the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// Zero time is returned for non-existing keys path or for invalid value.
+func (v *Value) GetUnixTime(keys ...string) time.Time {
+	r := v.Get(keys...)
+	if r == nil {
+		return time.Time{}
+	}
+	t, _ := r.UnixTime()
+	return t
+}
+
+// GetUnixMilli returns time from Unix milliseconds by the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// Zero time is returned for non-existing keys path or for invalid value.
+func (v *Value) GetUnixMilli(keys ...string) time.Time {
+	r := v.Get(keys...)
+	if r == nil {
+		return time.Time{}
+	}
+	t, _ := r.UnixMilli()
+	return t
+}
+
+// GetDuration returns duration by the given keys path.
+//
+// See Value.Duration for supported formats.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// 0 is returned for non-existing keys path or for invalid value.
+func (v *Value) GetDuration(keys ...string) time.Duration {
+	r := v.Get(keys...)
+	if r == nil {
+		return 0
+	}
+	d, _ := r.Duration()
+	return d
+}
+
+// GetBase64 returns decoded base64 payload by the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// nil is returned for non-existing keys path or for invalid value.
+func (v *Value) GetBase64(keys ...string) []byte {
+	r := v.Get(keys...)
+	if r == nil {
+		return nil
+	}
+	b, _ := r.Base64()
+	return b
+}
+
+// GetURL returns URL by the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+//
+// nil is returned for non-existing keys path or for invalid value.
+func (v *Value) GetURL(keys ...string) *url.URL {
+	r := v.Get(keys...)
+	if r == nil {
+		return nil
+	}
+	u, _ := r.URL()
+	return u
+}
@@ -0,0 +1,197 @@
+package jsonpart
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Del deletes the entry with the given key from o.
+func (o *Object) Del(key string) {
+	if o == nil {
+		return
+	}
+	o.checkMutable()
+	if !o.keysUnescaped && strings.IndexByte(key, '\\') < 0 {
+		// Fast path - try searching for the key without object keys unescaped.
+		for i, kv := range o.kvs {
+			if kv.k == key {
+				o.kvs = append(o.kvs[:i], o.kvs[i+1:]...)
+				return
+			}
+		}
+	}
+
+	// Slow path - unescape object keys before item search.
+	o.unescapeKeys()
+
+	for i, kv := range o.kvs {
+		if kv.k == key {
+			o.kvs = append(o.kvs[:i], o.kvs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Del deletes the entry with the last key in the given keys path.
+//
+// Array indexes may be represented as decimal numbers in keys.
+// Nothing is deleted for non-existing keys path.
+func (v *Value) Del(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	v = v.Get(keys[:len(keys)-1]...)
+	if v == nil {
+		return
+	}
+	key := keys[len(keys)-1]
+	if v.t == TypeObject {
+		v.o.Del(key)
+		return
+	}
+	if v.t == TypeArray {
+		v.checkMutable()
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 0 || n >= len(v.a) {
+			return
+		}
+		v.a = append(v.a[:n], v.a[n+1:]...)
+	}
+}
+
+// Set sets (key, value) entry in the o.
+//
+// nil value is stored as new null value.
+//
+// The value must be unchanged during o lifetime.
+func (o *Object) Set(key string, value *Value) {
+	if o == nil {
+		return
+	}
+	o.checkMutable()
+	if value == nil {
+		value = newNull()
+	}
+	o.unescapeKeys()
+
+	// Try substituting already existing entry with the given key.
+	for i := range o.kvs {
+		kv := &o.kvs[i]
+		if kv.k == key {
+			kv.v = value
+			return
+		}
+	}
+
+	// Add new entry.
+	kv := o.getKV()
+	kv.k = key
+	kv.v = value
+}
+
+// Set sets (key, value) entry in the array or object v.
+//
+// Array indexes must be decimal numbers. See SetArrayItem for details.
+//
+// The value must be unchanged during v lifetime.
+func (v *Value) Set(key string, value *Value) {
+	if v == nil {
+		return
+	}
+	switch v.t {
+	case TypeObject:
+		v.o.Set(key, value)
+	case TypeArray:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 {
+			return
+		}
+		v.SetArrayItem(idx, value)
+	}
+}
+
+// SetPath sets the value at the given keys path in v.
+//
+// The last key is set via Set in the value located by the preceding keys.
+// Missing intermediate objects are created, so v.SetPath([]string{"a", "b"}, value)
+// works for empty object v. Nothing is set if keys are empty or if the keys
+// path passes through a value, which isn't an object or an array.
+//
+// The value must be unchanged during v lifetime.
+func (v *Value) SetPath(keys []string, value *Value) {
+	if v == nil || len(keys) == 0 {
+		return
+	}
+	// Missing intermediate objects are allocated at once.
+	var objs []Value
+	for i, key := range keys[:len(keys)-1] {
+		next := v.Get(key)
+		if next == nil {
+			if v.t != TypeObject {
+				return
+			}
+			if objs == nil {
+				// All the keys after the missing one are missing too.
+				objs = make([]Value, len(keys)-1-i)
+			}
+			next = &objs[0]
+			objs = objs[1:]
+			next.t = TypeObject
+			next.o.keysUnescaped = true
+			v.o.Set(key, next)
+		}
+		v = next
+	}
+	v.Set(keys[len(keys)-1], value)
+}
+
+// SetArrayItem sets the value in the array v at idx position.
+//
+// The array is extended with nulls if idx exceeds its length.
+// nil value is stored as new null value.
+//
+// The value must be unchanged during v lifetime.
+func (v *Value) SetArrayItem(idx int, value *Value) {
+	if v == nil || v.t != TypeArray {
+		return
+	}
+	v.checkMutable()
+	if idx >= len(v.a) {
+		// Padding nulls are allocated at once.
+		nulls := make([]Value, idx+1-len(v.a))
+		for i := range nulls {
+			nulls[i].t = TypeNull
+			v.a = append(v.a, &nulls[i])
+		}
+	}
+	if value == nil {
+		value = newNull()
+	}
+	v.a[idx] = value
+}
+
+// Append appends the given values to the array v.
+//
+// nil values are stored as new null values.
+//
+// The values must be unchanged during v lifetime.
+func (v *Value) Append(values ...*Value) {
+	if v == nil || v.t != TypeArray {
+		return
+	}
+	v.checkMutable()
+	for _, value := range values {
+		if value == nil {
+			value = newNull()
+		}
+		v.a = append(v.a, value)
+	}
+}
+
+// newNull returns new null value for storing instead of nil value.
+//
+// The shared valueNull mustn't be stored in modifiable values,
+// since it cannot be unmarshaled into.
+func newNull() *Value {
+	return &Value{t: TypeNull}
+}
@@ -0,0 +1,120 @@
+package jsonpart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// UTF8Policy controls handling of invalid UTF-8 bytes and lone surrogate
+// escapes such as \uD83D in strings and object keys.
+type UTF8Policy int
+
+const (
+	// UTF8Keep keeps invalid UTF-8 bytes as is, while lone surrogate escapes
+	// are stored as literal text like \uD83D.
+	//
+	// This is the default policy.
+	UTF8Keep UTF8Policy = 0
+
+	// UTF8Replace replaces every invalid UTF-8 byte and every lone surrogate
+	// escape with U+FFFD replacement char.
+	UTF8Replace UTF8Policy = 1
+
+	// UTF8Error makes parsing fail on invalid UTF-8 bytes and lone surrogate escapes.
+	UTF8Error UTF8Policy = 2
+)
+
+// sanitizeUTF8 handles invalid UTF-8 bytes in s according to policy.
+func sanitizeUTF8(s string, policy UTF8Policy) (string, error) {
+	if policy == UTF8Keep || utf8.ValidString(s) {
+		return s, nil
+	}
+	b := make([]byte, 0, len(s)+8)
+	start := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			if policy == UTF8Error {
+				return "", fmt.Errorf("invalid UTF-8 byte 0x%02x at position %d in string", s[i], i)
+			}
+			b = append(b, s[start:i]...)
+			b = append(b, string(utf8.RuneError)...)
+			start = i + 1
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return b2s(b), nil
+}
+
+// replaceLoneSurrogates replaces lone surrogate escapes stored as literal text
+// according to UTF8Keep policy with U+FFFD, so the result matches UTF8Replace policy.
+//
+// It must be called only on strings containing such escapes,
+// since it cannot distinguish them from the same text produced by escapes like \\\\uD800.
+func replaceLoneSurrogates(s string) string {
+	b := make([]byte, 0, len(s))
+	for {
+		n := strings.Index(s, `\u`)
+		if n < 0 || len(s) < n+6 {
+			break
+		}
+		x, err := strconv.ParseUint(s[n+2:n+6], 16, 16)
+		if err != nil || !utf16.IsSurrogate(rune(x)) {
+			b = append(b, s[:n+2]...)
+			s = s[n+2:]
+			continue
+		}
+		b = append(b, s[:n]...)
+		b = append(b, string(utf8.RuneError)...)
+		s = s[n+6:]
+	}
+	b = append(b, s...)
+	return b2s(b)
+}
+
+// applyUTF8Policy unescapes all the strings and object keys in v according to policy.
+//
+// path is the keys path to v. It is used in error messages.
+func (v *Value) applyUTF8Policy(policy UTF8Policy, path []string) error {
+	var err error
+	switch v.t {
+	case typeRawString:
+		v.s, err = unescapeString(v.s, policy)
+		v.t = TypeString
+	case TypeString:
+		v.s, err = sanitizeUTF8(v.s, policy)
+	case TypeObject:
+		kvs := v.o.kvs
+		for i := range kvs {
+			kv := &kvs[i]
+			if v.o.keysUnescaped {
+				kv.k, err = sanitizeUTF8(kv.k, policy)
+			} else {
+				kv.k, err = unescapeString(kv.k, policy)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid object key at %q: %w", FormatPointer(path), err)
+			}
+		}
+		v.o.keysUnescaped = true
+		for _, kv := range kvs {
+			if err := kv.v.applyUTF8Policy(policy, append(path, kv.k)); err != nil {
+				return err
+			}
+		}
+	case TypeArray:
+		for i, item := range v.a {
+			if err := item.applyUTF8Policy(policy, append(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid string at %q: %w", FormatPointer(path), err)
+	}
+	return nil
+}